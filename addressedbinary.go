@@ -0,0 +1,107 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"errors"
+)
+
+// AddressedBinary is a Type 6 message
+type AddressedBinary struct {
+	Repeat     uint8
+	MMSI       uint32
+	Sequence   uint8 // Sequence number
+	DestMMSI   uint32
+	Retransmit bool
+	DAC        uint16
+	FID        uint8
+	Data       string // The whole armoured payload, kept for the decoders of binary applications
+
+	padding uint8 // Fill bits at the end of Data, if known
+}
+
+// DecodeAddressedBinary decodes [the payload of] an AIS Addressed Binary message (Type 6) but not its binary payload
+//
+// The fill bits of the sentence aren't known, so they are counted as application data. Use
+// DecodeAddressedBinaryPadded to remove them.
+func DecodeAddressedBinary(payload string) (AddressedBinary, error) {
+	r := NewBitReader(payload)
+	var m AddressedBinary
+
+	mType := uint8(r.Uint(0, 5))
+	if mType != 6 {
+		return m, errors.New("Message isn't Addressed Binary (type 6).")
+	}
+
+	m.Repeat = uint8(r.Uint(6, 7))
+
+	m.MMSI = uint32(r.Uint(8, 37))
+
+	m.Sequence = uint8(r.Uint(38, 39))
+	m.DestMMSI = uint32(r.Uint(40, 69))
+	m.Retransmit = r.Bool(70)
+
+	m.DAC = uint16(r.Uint(72, 81))
+	m.FID = uint8(r.Uint(82, 87))
+
+	m.Data = payload // Data start at bit 88, but this way we simplify our code
+
+	return m, nil
+}
+
+// DecodeAddressedBinaryPadded decodes an AIS Addressed Binary message (Type 6), removing the fill bits
+// (padding) from its application data. Unlike DecodeAddressedBinary it fails on payloads with invalid
+// characters.
+func DecodeAddressedBinaryPadded(payload string, padding uint8) (AddressedBinary, error) {
+	if _, _, err := DearmorPayload(payload, padding); err != nil {
+		return AddressedBinary{}, err
+	}
+	m, err := DecodeAddressedBinary(payload)
+	if err != nil {
+		return m, err
+	}
+	m.padding = padding
+	return m, nil
+}
+
+// ApplicationData returns the application data of the message (the bits after the FID) packed in
+// bytes, and their number. Data with invalid characters give no application data.
+func (m AddressedBinary) ApplicationData() ([]byte, int) {
+	data, size, err := DearmorPayload(m.Data, m.padding)
+	if err != nil {
+		return nil, 0
+	}
+	app := NewBitReaderBytes(data, size).Slice(88, size-1)
+	return app.Bytes(), app.Len()
+}
+
+// Some Addressed Binary types.
+var BinaryAddressedType = map[int]map[int]string{
+	1: {
+		0:  "Text telegram",
+		2:  "Capability interrogation",
+		3:  "Capability reply",
+		12: "Dangerous cargo indication",
+		14: "Tidal window",
+		16: "Number of persons on board",
+		18: "Clearance time to enter port",
+		20: "Berthing data",
+		30: "Text description addressed",
+		32: "Tidal window",
+	},
+}
@@ -46,45 +46,78 @@ var EpfdFixTypes = [...]string{
 
 // DecodeBaseStationReport decodes the payload of a Type 4 AIS message
 func DecodeBaseStationReport(payload string) (BaseStationReport, error) {
-	data := []byte(payload)
+	r := NewBitReader(payload)
 	var m BaseStationReport
 
-	mType := decodeAisChar(data[0])
+	mType := uint8(r.Uint(0, 5))
 	if mType != 4 {
 		return m, errors.New("Message isn't Base Station Report (type 4).")
 	}
 
 	//m.Repeat = decodeAisChar(data[1]) >> 4
-	m.Repeat = uint8(bitsToInt(6, 7, data))
+	m.Repeat = uint8(r.Uint(6, 7))
 
 	//m.MMSI = uint32(decodeAisChar(data[1]))<<28>>2 | uint32(decodeAisChar(data[2]))<<20 |
 	//	uint32(decodeAisChar(data[3]))<<14 | uint32(decodeAisChar(data[4]))<<8 |
 	//	uint32(decodeAisChar(data[5]))<<2 | uint32(decodeAisChar(data[6]))>>4
-	m.MMSI = bitsToInt(8, 37, data)
+	m.MMSI = uint32(r.Uint(8, 37))
 
 	m.Time, _ = GetReferenceTime(payload) // Some base stations do not report time, for this case we do not consider it as error
 
-	m.Accuracy = cbnBool(78, data)
+	m.Accuracy = r.Bool(78)
 
-	m.Lon, m.Lat = cbnCoordinates(79, data)
+	m.Lon, m.Lat = cbnCoordinates(79, r)
 
-	m.EPFD = uint8(bitsToInt(134, 137, data))
+	m.EPFD = uint8(r.Uint(134, 137))
 
-	m.RAIM = cbnBool(148, data)
+	m.RAIM = r.Bool(148)
 
-	m.Radio = bitsToInt(149, 167, data)
+	m.Radio = uint32(r.Uint(149, 167))
 	return m, nil
 }
 
+// EncodeBaseStationReport encodes a Base Station Report (type 4) to an AIS message, the inverse
+// of DecodeBaseStationReport. A zero Time is written as not available.
+func EncodeBaseStationReport(m BaseStationReport) (Message, error) {
+	w := NewBitWriter(168)
+
+	w.Uint(0, 5, 4)
+	w.Uint(6, 7, uint64(m.Repeat))
+	w.Uint(8, 37, uint64(m.MMSI))
+
+	if m.Time.IsZero() {
+		w.Uint(61, 65, 24)
+		w.Uint(66, 71, 60)
+		w.Uint(72, 77, 60)
+	} else {
+		t := m.Time.UTC() // AIS time is UTC
+		w.Uint(38, 51, uint64(t.Year()))
+		w.Uint(52, 55, uint64(t.Month()))
+		w.Uint(56, 60, uint64(t.Day()))
+		w.Uint(61, 65, uint64(t.Hour()))
+		w.Uint(66, 71, uint64(t.Minute()))
+		w.Uint(72, 77, uint64(t.Second()))
+	}
+
+	w.Bool(78, m.Accuracy)
+	cbnPutCoordinates(79, w, m.Lon, m.Lat)
+	w.Uint(134, 137, uint64(m.EPFD))
+	w.Bool(148, m.RAIM)
+	w.Uint(149, 167, uint64(m.Radio))
+
+	payload, padding := w.Payload()
+	return Message{Type: 4, Payload: payload, Padding: padding}, nil
+}
+
 // GetReferenceTime takes [the payload of] an AIS Base Station message (type 4)
 // and returns the time data of it. It is a separate function from DecodeBaseStationReport
 // because it can be useful to set a timeframe for our received AIS messages.
 func GetReferenceTime(payload string) (time.Time, error) {
-	data := []byte(payload)
+	r := NewBitReader(payload)
 
 	//year := uint16(decodeAisChar(data[6]))<<12>>2 | uint16(decodeAisChar(data[7]))<<4 |
 	//	uint16(decodeAisChar(data[8]))>>2
-	year := bitsToInt(38, 51, data)
+	year := r.Uint(38, 51)
 	if year == 0 {
 		var t time.Time
 		return t, errors.New("station doesn't report time")
@@ -95,11 +128,11 @@ func GetReferenceTime(payload string) (time.Time, error) {
 	//hour := decodeAisChar(data[10]) << 3 >> 3
 	//minute := decodeAisChar(data[11])
 	//second := decodeAisChar(data[12])
-	month := bitsToInt(52, 55, data)
-	day := bitsToInt(56, 60, data)
-	hour := bitsToInt(61, 65, data)
-	minute := bitsToInt(66, 71, data)
-	second := bitsToInt(72, 77, data)
+	month := r.Uint(52, 55)
+	day := r.Uint(56, 60)
+	hour := r.Uint(61, 65)
+	minute := r.Uint(66, 71)
+	second := r.Uint(72, 77)
 
 	timeString := fmt.Sprintf("%d/%d/%d %d:%d:%d", year, month, day, hour, minute, second)
 	t, _ := time.Parse("2006/1/2 15:4:5", timeString)
@@ -55,6 +55,38 @@ func TestDecodeBaseStationReport(t *testing.T) {
 	}
 }
 
+func TestEncodeBaseStationReport(t *testing.T) {
+	payloads := []string{"402R3KiutR0Qk156V4QQTOA00<0;", "4025boiutR0Qj0qgK<OodKW00@N1"}
+	for _, payload := range payloads {
+		m, _ := DecodeBaseStationReport(payload)
+		got, err := EncodeBaseStationReport(m)
+		if err != nil || got != (Message{Type: 4, Payload: payload, Padding: 0}) {
+			fmt.Println("Got : ", got, err)
+			fmt.Println("Want: ", payload)
+			t.Errorf("EncodeBaseStationReport(m BaseStationReport)")
+		}
+	}
+
+	// Times of other zones are sent in UTC
+	m, _ := DecodeBaseStationReport(payloads[0])
+	m.Time = m.Time.In(time.FixedZone("LINT", 14*3600))
+	if got, err := EncodeBaseStationReport(m); err != nil || got.Payload != payloads[0] {
+		fmt.Println("Got : ", got, err)
+		fmt.Println("Want: ", payloads[0])
+		t.Errorf("EncodeBaseStationReport(m BaseStationReport) with time %v", m.Time)
+	}
+
+	// Station that doesn't report time
+	want := BaseStationReport{Repeat: 0, MMSI: 2655087, Accuracy: true, Lon: -70.5, Lat: -33.25, EPFD: 7, Radio: 1}
+	e, err := EncodeBaseStationReport(want)
+	got, _ := DecodeBaseStationReport(e.Payload)
+	if err != nil || got != want {
+		fmt.Println("Got : ", got, err)
+		fmt.Println("Want: ", want)
+		t.Errorf("EncodeBaseStationReport(m BaseStationReport)")
+	}
+}
+
 func BenchmarkDecodeBaseStationReport(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		DecodeBaseStationReport("402R3KiutR0Qk156V4QQTOA00<0;")
@@ -27,34 +27,99 @@ type BinaryBroadcast struct {
 	MMSI   uint32
 	DAC    uint16
 	FID    uint8
-	Data   string
+	Data   string // The whole armoured payload, kept for the decoders of binary applications
+
+	padding uint8 // Fill bits at the end of Data, if known
 }
 
 // DecodeBinaryBroadcast decodes [the payload of] an AIS Binary Broadcast message (Type 8) but not its binary payload
+//
+// The fill bits of the sentence aren't known, so they are counted as application data. Use
+// DecodeBinaryBroadcastPadded to remove them.
 func DecodeBinaryBroadcast(payload string) (BinaryBroadcast, error) {
-	data := []byte(payload)
+	r := NewBitReader(payload)
 	var m BinaryBroadcast
 
-	mType := decodeAisChar(data[0])
+	mType := uint8(r.Uint(0, 5))
 	if mType != 8 {
 		return m, errors.New("Message isn't Binary Broadcast (type 8).")
 	}
 
-	m.Repeat = uint8(bitsToInt(6, 7, data))
+	m.Repeat = uint8(r.Uint(6, 7))
 
-	m.MMSI = uint32(bitsToInt(8, 37, data))
+	m.MMSI = uint32(r.Uint(8, 37))
 
-	m.DAC = uint16(bitsToInt(40, 49, data))
-	m.FID = uint8(bitsToInt(50, 55, data))
+	m.DAC = uint16(r.Uint(40, 49))
+	m.FID = uint8(r.Uint(50, 55))
 
 	m.Data = payload // Data start at bit 56, but this way we simplify our code
 
 	return m, nil
 }
 
+// DecodeBinaryBroadcastPadded decodes an AIS Binary Broadcast message (Type 8), removing the fill bits
+// (padding) from its application data. Unlike DecodeBinaryBroadcast it fails on payloads with invalid
+// characters.
+func DecodeBinaryBroadcastPadded(payload string, padding uint8) (BinaryBroadcast, error) {
+	if _, _, err := DearmorPayload(payload, padding); err != nil {
+		return BinaryBroadcast{}, err
+	}
+	m, err := DecodeBinaryBroadcast(payload)
+	if err != nil {
+		return m, err
+	}
+	m.padding = padding
+	return m, nil
+}
+
+// ApplicationData returns the application data of the message (the bits after the FID) packed in
+// bytes, and their number. Data with invalid characters give no application data.
+func (m BinaryBroadcast) ApplicationData() ([]byte, int) {
+	data, size, err := DearmorPayload(m.Data, m.padding)
+	if err != nil {
+		return nil, 0
+	}
+	app := NewBitReaderBytes(data, size).Slice(56, size-1)
+	return app.Bytes(), app.Len()
+}
+
+// BinaryHeader holds the fields every binary message carries before its application
+// data. It is embedded in the types of the decoded binary applications, since most of
+// them may arrive either as a Binary Broadcast (type 8) or an Addressed Binary (type 6).
+type BinaryHeader struct {
+	Type     uint8
+	Repeat   uint8
+	MMSI     uint32
+	DestMMSI uint32 // Only for addressed messages (type 6)
+	DAC      uint16
+	FID      uint8
+}
+
+// decodeBinaryHeader decodes the header of a type 6 or type 8 message. It also returns the
+// bit where the application data start, so decoders work for both message types.
+func decodeBinaryHeader(r *BitReader) (BinaryHeader, int) {
+	var h BinaryHeader
+
+	h.Type = uint8(r.Uint(0, 5))
+	h.Repeat = uint8(r.Uint(6, 7))
+	h.MMSI = uint32(r.Uint(8, 37))
+
+	if h.Type == 6 {
+		h.DestMMSI = uint32(r.Uint(40, 69))
+		h.DAC = uint16(r.Uint(72, 81))
+		h.FID = uint8(r.Uint(82, 87))
+		return h, 88
+	}
+
+	h.DAC = uint16(r.Uint(40, 49))
+	h.FID = uint8(r.Uint(50, 55))
+	return h, 56
+}
+
 // Some Binary Broadcast types. The list isn't complete but I haven't searched for a better source
 var BinaryBroadcastType = map[int]map[int]string{
 	1: {
+		0:  "Text telegram",
 		11: "Meteorological/Hydrogological Data",
 		13: "Fairway closed",
 		15: "Extended ship and voyage",
@@ -67,6 +132,7 @@ var BinaryBroadcastType = map[int]map[int]string{
 		27: "Route info broadcast",
 		29: "Text description broadcast",
 		31: "Meteorological and Hydrological",
+		40: "Number of persons on board",
 	},
 	200: {
 		10: "Ship static and voyage related data",
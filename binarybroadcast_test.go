@@ -0,0 +1,75 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestDecodeBinaryBroadcastPadded(t *testing.T) {
+	payload := "85Mwp`00GH181B2?EBP3<?C54P4E5PD?P4B5479>7dPEC5P>?BD8P381>>5<"
+	want := BinaryBroadcast{Repeat: 0, MMSI: 366999712, DAC: 1, FID: 29, Data: payload}
+	wantData := []byte{0x80, 0x48, 0x05, 0x20, 0x8f, 0x55, 0x28, 0x03, 0x30, 0xf4, 0xc5, 0x12, 0x01,
+		0x15, 0x16, 0x05, 0x0f, 0x80, 0x44, 0x85, 0x10, 0x72, 0x4e, 0x1e, 0xc8, 0x15, 0x4c, 0x58,
+		0x0e, 0x3d, 0x25, 0x08, 0x80, 0x32, 0x01, 0x38, 0xe1, 0x4c}
+
+	got, err := DecodeBinaryBroadcastPadded(payload, 0)
+	data, bits := got.ApplicationData()
+	if err != nil || got != want || bits != 304 || !bytes.Equal(data, wantData) {
+		fmt.Println("Got : ", got, bits, data)
+		fmt.Println("Want: ", want, 304, wantData)
+		t.Errorf("DecodeBinaryBroadcastPadded(payload string, padding uint8)")
+	}
+
+	if _, err := DecodeBinaryBroadcastPadded(payload, 6); err == nil {
+		t.Errorf("DecodeBinaryBroadcastPadded(payload string, padding uint8) accepted padding 6")
+	}
+	if _, err := DecodeBinaryBroadcastPadded("15M67FC000G?ufbE`FepT@3n00Sa", 0); err == nil {
+		t.Errorf("DecodeBinaryBroadcastPadded(payload string, padding uint8) accepted a type 1 message")
+	}
+
+	// DecodeBinaryBroadcast doesn't check the characters, like it always did
+	if got, err := DecodeBinaryBroadcast(payload + "!"); err != nil || got.MMSI != want.MMSI {
+		t.Errorf("DecodeBinaryBroadcast(payload string) = %v, %v", got, err)
+	} else if data, bits := got.ApplicationData(); data != nil || bits != 0 {
+		t.Errorf("BinaryBroadcast.ApplicationData() = %v, %d for invalid data", data, bits)
+	}
+}
+
+func TestDecodeAddressedBinaryPadded(t *testing.T) {
+	payload := "602R3KlwLamP05pC@QDU28<L`0DljAkQA0"
+	want := []byte{0x13, 0x42, 0x15, 0x25, 0x08, 0x83, 0x1c, 0xa0, 0x05, 0x34, 0xc9, 0x1c, 0xe1, 0x44, 0x00}
+
+	got, err := DecodeAddressedBinaryPadded(payload, 2)
+	data, bits := got.ApplicationData()
+	if err != nil || got.MMSI != 2655087 || got.DestMMSI != 266119000 || got.DAC != 1 || got.FID != 30 ||
+		bits != 114 || !bytes.Equal(data, want) {
+		fmt.Println("Got : ", got, bits, data)
+		fmt.Println("Want: ", 114, want)
+		t.Errorf("DecodeAddressedBinaryPadded(payload string, padding uint8)")
+	}
+
+	// Without padding information the fill bits are part of the data
+	if got, _ := DecodeAddressedBinary(payload); got.Data != payload {
+		t.Errorf("DecodeAddressedBinary(payload string) Data = %q, want %q", got.Data, payload)
+	} else if _, bits := got.ApplicationData(); bits != 116 {
+		t.Errorf("AddressedBinary.ApplicationData() bits = %d, want 116", bits)
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"errors"
+	"strings"
+)
+
+// BitReader reads fields from the binary data of an AIS message. Fields are addressed by their
+// first and last bit (both inclusive, counting from 0), as they are listed in the specifications.
+//
+// Some transmitters send messages shorter than the specifications mandate. Thus, like most
+// decoders do, reading a number beyond the end of the data returns 0 and reading text beyond
+// the end of the data returns only the characters available.
+type BitReader struct {
+	data []byte // Bits packed in bytes, most significant bit first
+	size int    // Number of bits
+}
+
+// NewBitReader returns a BitReader over an armoured (six bit ASCII) AIS payload.
+func NewBitReader(payload string) *BitReader {
+	return &BitReader{dearmor(payload), len(payload) * 6}
+}
+
+// DearmorPayload converts an armoured (six bit ASCII) AIS payload to bytes, most significant
+// bit first. Padding is the number of fill bits of the sentence carrying the last part of
+// the payload; they are removed from the returned bit count and cleared from the data.
+func DearmorPayload(payload string, padding uint8) ([]byte, int, error) {
+	if padding > 5 {
+		return nil, 0, errors.New("Padding should be between 0 and 5.")
+	}
+	for i := 0; i < len(payload); i++ {
+		if c := payload[i]; c < 48 || c > 119 || (c > 87 && c < 96) {
+			return nil, 0, errors.New("Payload contains invalid character '" + string(c) + "'.")
+		}
+	}
+	size := len(payload)*6 - int(padding)
+	if size < 0 {
+		return nil, 0, errors.New("Padding is larger than the payload.")
+	}
+	return NewBitReaderBytes(dearmor(payload), size).Bytes(), size, nil
+}
+
+// dearmor packs the six bit characters of a payload in bytes.
+func dearmor(payload string) []byte {
+	data := make([]byte, (len(payload)*6+7)/8)
+
+	// Collect six bit characters and write out every full byte
+	acc, n, j := uint(0), uint(0), 0
+	for i := 0; i < len(payload); i++ {
+		acc = acc<<6 | uint(decodeAisChar(payload[i])&0x3f)
+		n += 6
+		if n >= 8 {
+			n -= 8
+			data[j] = byte(acc >> n)
+			acc &= 1<<n - 1
+			j++
+		}
+	}
+	if n > 0 {
+		data[j] = byte(acc << (8 - n))
+	}
+	return data
+}
+
+// NewBitReaderBytes returns a BitReader over the first size bits of data. Bits are read from
+// the most significant bit of each byte.
+func NewBitReaderBytes(data []byte, size int) *BitReader {
+	if size > len(data)*8 {
+		size = len(data) * 8
+	}
+	return &BitReader{data, size}
+}
+
+// put writes the n rightmost bits of value starting at bit first. Used only while
+// creating a reader.
+func (r *BitReader) put(first int, value uint64, n int) {
+	for i := 0; i < n; i++ {
+		if value>>uint(n-1-i)&1 == 1 {
+			bit := first + i
+			r.data[bit/8] |= 0x80 >> uint(bit%8)
+		}
+	}
+}
+
+// Len returns the number of bits available.
+func (r *BitReader) Len() int {
+	return r.size
+}
+
+// Uint reads an unsigned integer of up to 64 bits.
+func (r *BitReader) Uint(first, last int) uint64 {
+	if first < 0 || last < first || last-first >= 64 || last >= r.size {
+		return 0
+	}
+	var value uint64
+	// Read as many bits as possible from each byte
+	for i := first; i <= last; {
+		offset := uint(i % 8)
+		n := 8 - offset
+		if remain := uint(last - i + 1); remain < n {
+			n = remain
+		}
+		value = value<<n | uint64(r.data[i/8]<<offset>>(8-n))
+		i += int(n)
+	}
+	return value
+}
+
+// Int reads a signed (two's complement) integer of up to 64 bits.
+func (r *BitReader) Int(first, last int) int64 {
+	shift := uint(64 - (last - first + 1))
+	return int64(r.Uint(first, last)<<shift) >> shift
+}
+
+// Bool reads a single bit flag.
+func (r *BitReader) Bool(bit int) bool {
+	return r.Uint(bit, bit) == 1
+}
+
+// String reads six bit ASCII text of any length. Trailing spaces and @ (padding) are removed,
+// according to the format specs.
+func (r *BitReader) String(first, last int) string {
+	// Text fields have constant size but transmitters frequently send shorter messages when
+	// the text doesn't occupy the whole field. We read as many characters as are available.
+	if last >= r.size {
+		last = r.size - 1
+	}
+	length := (last - first + 1) / 6
+	if first < 0 || length <= 0 {
+		return ""
+	}
+
+	text := make([]byte, length)
+	for i := range text {
+		char := byte(r.Uint(first+i*6, first+i*6+5))
+		if char < 32 {
+			char += 64
+		}
+		text[i] = char
+	}
+
+	return strings.TrimRight(string(text), "@ ")
+}
+
+// Slice returns a new BitReader over bits first to last. It is useful to hand the application
+// data of binary messages to DAC/FID specific decoders, so they can count bits from 0.
+func (r *BitReader) Slice(first, last int) *BitReader {
+	if last >= r.size {
+		last = r.size - 1
+	}
+	if first < 0 || last < first {
+		return &BitReader{}
+	}
+	s := &BitReader{make([]byte, (last-first+8)/8), last - first + 1}
+	for i := first; i <= last; i += 64 {
+		end := i + 63
+		if end > last {
+			end = last
+		}
+		s.put(i-first, r.Uint(i, end), end-i+1)
+	}
+	return s
+}
+
+// Bytes returns the bits of the reader packed in bytes, most significant bit first. If the
+// number of bits isn't a multiple of 8, the last byte is padded with zeros.
+func (r *BitReader) Bytes() []byte {
+	data := make([]byte, (r.size+7)/8)
+	copy(data, r.data)
+	// Clear bits past the end, the data may come from a larger slice
+	if extra := uint(len(data)*8 - r.size); extra > 0 {
+		data[len(data)-1] &= 0xff << extra
+	}
+	return data
+}
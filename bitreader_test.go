@@ -0,0 +1,144 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestBitReader(t *testing.T) {
+	r := NewBitReader("38u<a<?PAA2>P:WfuAO9PW<P0PuQ")
+
+	if r.Len() != 168 {
+		t.Errorf("BitReader.Len() = %d, want 168", r.Len())
+	}
+
+	cases := []struct {
+		first, last int
+		want        uint64
+	}{
+		{0, 5, 3},
+		{8, 37, 601041200},
+		{42, 49, 129},               // ROT, -127 as unsigned
+		{0, 63, 905026320669361424}, // widest field
+		{149, 167, 135009},          // radio, ends at the last bit
+		{160, 168, 0},               // beyond the end
+		{10, 9, 0},                  // invalid field
+		{0, 64, 0},                  // wider than 64 bits
+	}
+	for _, c := range cases {
+		if got := r.Uint(c.first, c.last); got != c.want {
+			t.Errorf("BitReader.Uint(%d, %d) = %d, want %d", c.first, c.last, got, c.want)
+		}
+	}
+
+	if got := r.Int(42, 49); got != -127 {
+		t.Errorf("BitReader.Int(42, 49) = %d, want -127", got)
+	}
+	if got := r.Int(61, 88); got != 18678099 {
+		t.Errorf("BitReader.Int(61, 88) = %d, want 18678099", got)
+	}
+	if got := r.Int(89, 115); got != -17870468 {
+		t.Errorf("BitReader.Int(89, 115) = %d, want -17870468", got)
+	}
+	if got := r.Bool(60); got != false {
+		t.Errorf("BitReader.Bool(60) = %t, want false", got)
+	}
+}
+
+func TestBitReaderString(t *testing.T) {
+	r := NewBitReader("53uJur01rN?U<9@T001@tI@F000000000000000l0pA444mm?:1km1@SlQp000000000000")
+	if got := r.String(112, 231); got != "TOFTE" {
+		t.Errorf("BitReader.String(112, 231) = %s, want TOFTE", got)
+	}
+
+	// Truncated field, only the characters available are read
+	r = NewBitReader("53m`0o400000hKGCON18E<=DF0:1")
+	if got := r.String(70, 111); got != "LF5477" {
+		t.Errorf("BitReader.String(70, 111) = %s, want LF5477", got)
+	}
+	if got := r.String(302, 421); got != "" {
+		t.Errorf("BitReader.String(302, 421) = %s, want empty string", got)
+	}
+
+	// Text longer than any fixed buffer
+	r = NewBitReader("800000@0G@" + strings.Repeat("1", 150))
+	if got := r.String(66, r.Len()-1); got != strings.Repeat("A", 149) {
+		t.Errorf("BitReader.String(66, %d) = %s", r.Len()-1, got)
+	}
+}
+
+func TestBitReaderSlice(t *testing.T) {
+	r := NewBitReader("85Mwp`00GH181B2?EBP3<?C54P4E5PD?P4B5479>7dPEC5P>?BD8P381>>5<")
+	s := r.Slice(56, r.Len()-1)
+	if s.Len() != r.Len()-56 {
+		t.Errorf("BitReader.Slice(56, %d).Len() = %d, want %d", r.Len()-1, s.Len(), r.Len()-56)
+	}
+	if got := s.Uint(0, 9); got != 513 {
+		t.Errorf("BitReader.Slice(56, %d).Uint(0, 9) = %d, want 513", r.Len()-1, got)
+	}
+	if got := s.String(10, s.Len()-1); got != "HARBOUR CLOSED DUE TO DREDGING, USE NORTH CHANNEL" {
+		t.Errorf("BitReader.Slice(56, %d).String(10, %d) = %s", r.Len()-1, s.Len()-1, got)
+	}
+
+	b := NewBitReaderBytes([]byte{0xAB, 0xCD, 0xEF}, 20)
+	if got := b.Bytes(); !bytes.Equal(got, []byte{0xAB, 0xCD, 0xE0}) {
+		t.Errorf("BitReader.Bytes() = %x, want abcde0", got)
+	}
+	if got := b.Slice(4, 15).Bytes(); !bytes.Equal(got, []byte{0xBC, 0xD0}) {
+		t.Errorf("BitReader.Slice(4, 15).Bytes() = %x, want bcd0", got)
+	}
+}
+
+func BenchmarkBitReader(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		r := NewBitReader("38u<a<?PAA2>P:WfuAO9PW<P0PuQ")
+		r.Uint(8, 37)
+	}
+}
+
+func TestDearmorPayload(t *testing.T) {
+	cases := []struct {
+		payload string
+		padding uint8
+		data    []byte
+		size    int
+		valid   bool
+	}{
+		{"", 0, []byte{}, 0, true},
+		{"w", 0, []byte{0xfc}, 6, true},
+		{"0w", 2, []byte{0x03, 0xc0}, 10, true},
+		{"1P`w", 0, []byte{0x06, 0x0a, 0x3f}, 24, true},
+		{"w", 6, nil, 0, false},
+		{"0", 5, []byte{0x00}, 1, true},
+		{"", 1, nil, 0, false},
+		{"1X", 0, nil, 0, false},
+		{"1x", 0, nil, 0, false},
+	}
+	for _, c := range cases {
+		data, size, err := DearmorPayload(c.payload, c.padding)
+		if (err == nil) != c.valid || !bytes.Equal(data, c.data) || size != c.size {
+			fmt.Println("Got : ", data, size, err)
+			fmt.Println("Want: ", c.data, c.size, c.valid)
+			t.Errorf("DearmorPayload(payload string, padding uint8)")
+		}
+	}
+}
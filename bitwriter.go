@@ -0,0 +1,136 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"errors"
+	"strings"
+)
+
+// BitWriter builds the binary data of an AIS message. Fields are addressed by their first and
+// last bit (both inclusive), like in BitReader, so encoders can follow the specifications.
+type BitWriter struct {
+	data []byte // Bits packed in bytes, most significant bit first
+	size int    // Number of bits
+}
+
+// NewBitWriter returns a BitWriter for a message of size bits, all set to zero.
+func NewBitWriter(size int) *BitWriter {
+	if size < 0 {
+		size = 0
+	}
+	return &BitWriter{make([]byte, (size+7)/8), size}
+}
+
+// Len returns the number of bits of the message.
+func (w *BitWriter) Len() int {
+	return w.size
+}
+
+// Uint writes an unsigned integer of up to 64 bits. Bits of value that don't fit in the field
+// are dropped. Fields beyond the end of the message are ignored.
+func (w *BitWriter) Uint(first, last int, value uint64) {
+	if first < 0 || last < first || last-first >= 64 || last >= w.size {
+		return
+	}
+	for i := last; i >= first; i-- {
+		mask := byte(0x80) >> uint(i%8)
+		if value&1 == 1 {
+			w.data[i/8] |= mask
+		} else {
+			w.data[i/8] &^= mask
+		}
+		value >>= 1
+	}
+}
+
+// Int writes a signed (two's complement) integer of up to 64 bits.
+func (w *BitWriter) Int(first, last int, value int64) {
+	w.Uint(first, last, uint64(value))
+}
+
+// Bool writes a single bit flag.
+func (w *BitWriter) Bool(bit int, value bool) {
+	if value {
+		w.Uint(bit, bit, 1)
+	} else {
+		w.Uint(bit, bit, 0)
+	}
+}
+
+// String writes six bit ASCII text, padded with @ to the size of the field. Lower case letters
+// are converted to upper case. Text that doesn't fit or has characters outside the six bit
+// ASCII set isn't written and an error is returned.
+func (w *BitWriter) String(first, last int, text string) error {
+	text = strings.ToUpper(text)
+	if len(text) > (last-first+1)/6 {
+		return errors.New("Text '" + text + "' is too long for its field.")
+	}
+	chars := make([]uint64, len(text))
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if c < 32 || c > 95 {
+			return errors.New("Text '" + text + "' contains characters not in six bit ASCII.")
+		}
+		chars[i] = uint64(c & 0x3f) // @ to _ are 0 to 31, space to ? keep their value
+	}
+	for i := first; i+5 <= last; i += 6 {
+		w.Uint(i, i+5, 0)
+	}
+	for i, c := range chars {
+		w.Uint(first+i*6, first+i*6+5, c)
+	}
+	return nil
+}
+
+// Bytes returns the bits of the message packed in bytes, most significant bit first.
+func (w *BitWriter) Bytes() []byte {
+	data := make([]byte, len(w.data))
+	copy(data, w.data)
+	return data
+}
+
+// Payload returns the message armoured in six bit ASCII and the number of fill bits (padding)
+// added to complete the last character.
+func (w *BitWriter) Payload() (string, uint8) {
+	return ArmorPayload(w.data, w.size)
+}
+
+// ArmorPayload converts the first size bits of data to an armoured (six bit ASCII) AIS payload.
+// It is the inverse of DearmorPayload and returns the number of fill bits (padding) it added.
+func ArmorPayload(data []byte, size int) (string, uint8) {
+	r := NewBitReaderBytes(data, size)
+	size = r.Len()
+	padding := (6 - size%6) % 6
+
+	payload := make([]byte, (size+5)/6)
+	for i := range payload {
+		last := i*6 + 5
+		if last >= size {
+			last = size - 1
+		}
+		c := byte(r.Uint(i*6, last) << uint(i*6+5-last))
+		if c < 40 {
+			c += 48
+		} else {
+			c += 56
+		}
+		payload[i] = c
+	}
+	return string(payload), uint8(padding)
+}
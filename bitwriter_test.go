@@ -0,0 +1,73 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestBitWriter(t *testing.T) {
+	w := NewBitWriter(30)
+	w.Uint(0, 5, 63)
+	w.Int(6, 13, -2)
+	w.Bool(14, true)
+	w.Uint(15, 17, 0xff) // Only the 3 rightmost bits fit
+	w.Uint(28, 31, 0xf)  // Beyond the end, ignored
+	w.Uint(0, 1, 0)      // Overwrite
+
+	if got := w.Bytes(); !bytes.Equal(got, []byte{0x3f, 0xfb, 0xc0, 0x00}) {
+		t.Errorf("BitWriter.Bytes() = %x, want 3ffbc000", got)
+	}
+
+	r := NewBitReaderBytes(w.Bytes(), w.Len())
+	if r.Uint(0, 5) != 15 || r.Int(6, 13) != -2 || !r.Bool(14) || r.Uint(15, 17) != 7 {
+		t.Errorf("BitReader can't read back BitWriter fields")
+	}
+
+	if err := w.String(0, 11, "abc"); err == nil {
+		t.Errorf("BitWriter.String(0, 11, \"abc\") accepted text longer than the field")
+	}
+	if err := w.String(0, 11, "a"); err != nil || NewBitReaderBytes(w.Bytes(), w.Len()).String(0, 11) != "A" {
+		t.Errorf("BitWriter.String(0, 11, \"a\") = %v", err)
+	}
+}
+
+func TestArmorPayload(t *testing.T) {
+	cases := []struct {
+		payload string
+		padding uint8
+	}{
+		{"", 0},
+		{"w", 0},
+		{"0t", 2}, // Fill bits are zero
+		{"1P`w", 0},
+		{"0", 5},
+		{"53uJur01rN?U<9@T001@tI@F000000000000000l0pA444mm?:1km1@SlQp000000000000", 2},
+	}
+	for _, c := range cases {
+		data, size, _ := DearmorPayload(c.payload, c.padding)
+		payload, padding := ArmorPayload(data, size)
+		if payload != c.payload || padding != c.padding {
+			fmt.Println("Got : ", payload, padding)
+			fmt.Println("Want: ", c.payload, c.padding)
+			t.Errorf("ArmorPayload(data []byte, size int)")
+		}
+	}
+}
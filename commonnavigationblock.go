@@ -17,31 +17,81 @@
 
 package aislib
 
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
 // Some fields are common across different type of messages. Thus here are functions
 // to decode them.
 
 // cbnCoordinates takes the start of the coordinates block and returns coordinates in
 // decimal degrees
-func cbnCoordinates(first int, data []byte) (float64, float64) {
-	lon := float64((int32(bitsToInt(first, first+27, data)) << 4)) / 16
-	lat := float64((int32(bitsToInt(first+28, first+54, data)) << 5)) / 32
+func cbnCoordinates(first int, r *BitReader) (float64, float64) {
+	lon := float64(r.Int(first, first+27))
+	lat := float64(r.Int(first+28, first+54))
+
+	return CoordinatesMin2Deg(lon, lat)
+}
+
+// cbnPutCoordinates writes coordinates in decimal degrees to a coordinates block.
+func cbnPutCoordinates(first int, w *BitWriter, lon, lat float64) {
+	minLon, minLat := CoordinatesDeg2Min(lon, lat)
+	w.Int(first, first+27, int64(minLon))
+	w.Int(first+28, first+54, int64(minLat))
+}
+
+// cbnCoordinatesLow takes the start of a low resolution coordinates block (25 bits longitude,
+// 24 bits latitude in 1/1000 minutes, as used by many binary messages) and returns coordinates
+// in decimal degrees
+func cbnCoordinatesLow(first int, r *BitReader) (float64, float64) {
+	lon := float64(r.Int(first, first+24)) * 10
+	lat := float64(r.Int(first+25, first+48)) * 10
 
 	return CoordinatesMin2Deg(lon, lat)
 }
 
 // cbnSpeed takes the start of the speed block and returns speed in knots or 1023.
-func cbnSpeed(first int, data []byte) float32 {
-	speed := float32(bitsToInt(first, first+9, data))
+func cbnSpeed(first int, r *BitReader) float32 {
+	speed := float32(r.Uint(first, first+9))
 	if speed < 1022 {
 		speed /= 10
 	}
 	return speed
 }
 
-// cbnBool decodes a bool value
-func cbnBool(bit int, data []byte) bool {
-	if bitsToInt(bit, bit, data) == 1 {
-		return true
+// cbnPutSpeed writes speed in knots (or the special values 1022 and 1023) to a speed block.
+func cbnPutSpeed(first int, w *BitWriter, speed float32) {
+	raw := uint64(1023)
+	switch {
+	case speed >= 1022:
+		raw = uint64(math.Min(float64(speed), 1023))
+	case speed >= 0:
+		raw = uint64(math.Min(math.Round(float64(speed)*10), 1022))
+	}
+	w.Uint(first, first+9, raw)
+}
+
+// cbnMonthDayTime decodes the month (4 bits), day (5 bits), hour (5 bits) and minute (6 bits)
+// timestamps of binary messages. Year isn't transmitted, so it is left at 0.
+func cbnMonthDayTime(first int, r *BitReader) time.Time {
+	timeString := fmt.Sprintf("%d/%d %d:%d", r.Uint(first, first+3), r.Uint(first+4, first+8),
+		r.Uint(first+9, first+13), r.Uint(first+14, first+19))
+	t, _ := time.Parse("1/2 15:4", timeString)
+	return t
+}
+
+// cbnPutMonthDayTime writes a timestamp as month, day, hour and minute. A zero time is written
+// as not available.
+func cbnPutMonthDayTime(first int, w *BitWriter, t time.Time) {
+	if t.IsZero() {
+		w.Uint(first+9, first+13, 24)
+		w.Uint(first+14, first+19, 60)
+		return
 	}
-	return false
+	w.Uint(first, first+3, uint64(t.Month()))
+	w.Uint(first+4, first+8, uint64(t.Day()))
+	w.Uint(first+9, first+13, uint64(t.Hour()))
+	w.Uint(first+14, first+19, uint64(t.Minute()))
 }
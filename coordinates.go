@@ -49,6 +49,12 @@ func CoordinatesMin2Deg(minLon, minLat float64) (float64, float64) {
 	return lonSign * lon, latSign * lat
 }
 
+// CoordinatesDeg2Min translates coordinates (lon, lat) in decimal degrees to decimal minutes (×10^4),
+// rounded to the resolution AIS messages use. It is the inverse of CoordinatesMin2Deg.
+func CoordinatesDeg2Min(degLon, degLat float64) (float64, float64) {
+	return math.Round(degLon * 600000), math.Round(degLat * 600000)
+}
+
 // CoordinatesDeg2Human takes coordinates (lon, lat) in decimal degrees (DD),
 // formats them as degrees minutes and returns them as string.
 func CoordinatesDeg2Human(degLon, degLat float64) string {
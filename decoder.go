@@ -0,0 +1,148 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"bufio"
+	"context"
+	"io"
+)
+
+// DefaultMaxLineLength is the longest line a Decoder accepts if MaxLineLength isn't set. It is
+// much longer than an NMEA183 sentence, to leave room for tag blocks and timestamps.
+const DefaultMaxLineLength = 1024
+
+// A Decoder reads AIS sentences from an io.Reader (a file, a gzip stream, a TCP connection)
+// and returns the messages they carry, one at a time. It uses the same logic as Router, so
+// multi-sentence messages are assembled and RouterOptions apply.
+//
+// Lines may end with LF or CRLF. Empty lines are skipped and lines longer than MaxLineLength
+// are reported as failed.
+type Decoder struct {
+	// Failed, if set, is called for every line that can't be used. It is called from Next.
+	Failed func(FailedSentence)
+	// MaxLineLength, if set before the first call to Next, overrides DefaultMaxLineLength.
+	MaxLineLength int
+
+	ctx     context.Context
+	reader  io.Reader
+	lines   chan decoderLine
+	err     error // The error that stopped reading, set before lines is closed
+	router  *router
+	queue   []Message
+	started bool
+	done    bool
+}
+
+// decoderLine is a line read by a Decoder, or the start of a line that was too long.
+type decoderLine struct {
+	text    string
+	tooLong bool
+}
+
+// NewDecoder returns a Decoder that reads from r until it ends or ctx is cancelled.
+func NewDecoder(ctx context.Context, r io.Reader, opts RouterOptions) *Decoder {
+	d := &Decoder{ctx: ctx, reader: r}
+	d.router = newRouter(opts, func(m Message) {
+		d.queue = append(d.queue, m)
+	}, func(f FailedSentence) {
+		if d.Failed != nil {
+			d.Failed(f)
+		}
+	})
+	return d
+}
+
+// Next returns the next message. At the end of the input it returns io.EOF, or the error that
+// stopped reading. If the context is cancelled it returns the context's error.
+//
+// A read in progress can't be interrupted, so for connections that may stall also set a read
+// deadline, or close the connection when cancelling.
+func (d *Decoder) Next() (Message, error) {
+	if !d.started {
+		d.started = true
+		d.lines = make(chan decoderLine, 64)
+		go d.read()
+	}
+
+	for len(d.queue) == 0 {
+		if d.done {
+			return Message{}, d.err
+		}
+		select {
+		case <-d.ctx.Done():
+			return Message{}, d.ctx.Err()
+		case line, ok := <-d.lines:
+			switch {
+			case !ok:
+				d.done = true
+			case line.tooLong:
+				if d.Failed != nil {
+					d.Failed(FailedSentence{line.text, "Line too long"})
+				}
+			default:
+				d.router.process(line.text)
+			}
+		}
+	}
+
+	m := d.queue[0]
+	d.queue = d.queue[1:]
+	return m, nil
+}
+
+// read reads lines and sends them to Next, until the end of the input or the cancellation of
+// the context.
+func (d *Decoder) read() {
+	defer close(d.lines)
+
+	max := d.MaxLineLength
+	if max <= 0 {
+		max = DefaultMaxLineLength
+	}
+	br := bufio.NewReaderSize(d.reader, max)
+
+	for {
+		data, isPrefix, err := br.ReadLine()
+		if err != nil {
+			d.err = err
+			return
+		}
+		line := decoderLine{text: string(data)}
+		if isPrefix { // Skip the rest of the line
+			line.tooLong = true
+			for isPrefix && err == nil {
+				_, isPrefix, err = br.ReadLine()
+			}
+		}
+		if len(line.text) == 0 {
+			continue
+		}
+
+		select {
+		case d.lines <- line:
+		case <-d.ctx.Done():
+			d.err = d.ctx.Err()
+			return
+		}
+		if err != nil {
+			d.err = err
+			return
+		}
+	}
+}
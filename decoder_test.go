@@ -0,0 +1,119 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecoder(t *testing.T) {
+	input := "!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F\r\n" +
+		"\r\n" +
+		"!AIVDM,2,1,5,A,533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H,0*44\n" +
+		"!AIVDM," + strings.Repeat("0", 200) + "\n" +
+		"!AIVDM,2,2,5,A,51CU0E2CkP0,2*0C\r\n" +
+		"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*00\n" +
+		"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F" // No newline at the end
+
+	want := []Message{
+		{Type: 3, Payload: "38u<a<?PAA2>P:WfuAO9PW<P0PuQ", Padding: 0},
+		{Type: 5, Payload: "533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H51CU0E2CkP0", Padding: 2},
+		{Type: 3, Payload: "38u<a<?PAA2>P:WfuAO9PW<P0PuQ", Padding: 0},
+	}
+	var failed []FailedSentence
+
+	d := NewDecoder(context.Background(), strings.NewReader(input), RouterOptions{})
+	d.MaxLineLength = 100
+	d.Failed = func(f FailedSentence) { failed = append(failed, f) }
+
+	for _, w := range want {
+		got, err := d.Next()
+		if err != nil || got != w {
+			fmt.Println("Got : ", got, err)
+			fmt.Println("Want: ", w)
+			t.Errorf("Decoder.Next()")
+		}
+	}
+	if _, err := d.Next(); err != io.EOF {
+		t.Errorf("Decoder.Next() = %v at the end of input, want io.EOF", err)
+	}
+	if _, err := d.Next(); err != io.EOF {
+		t.Errorf("Decoder.Next() = %v after the end of input, want io.EOF", err)
+	}
+
+	if len(failed) != 2 || failed[0].Issue != "Line too long" || failed[1].Issue != "Checksum failed" {
+		t.Errorf("Decoder.Failed got %v", failed)
+	}
+}
+
+func TestDecoderEmptyPayload(t *testing.T) {
+	input := "!AIVDM,1,1,,A,,0*26\n" +
+		"!AIVDM,2,1,5,A,533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H,0*44\n" +
+		"!AIVDM,2,2,5,A,,2*11\n"
+	var failed []FailedSentence
+
+	d := NewDecoder(context.Background(), strings.NewReader(input), RouterOptions{})
+	d.Failed = func(f FailedSentence) { failed = append(failed, f) }
+	if got, err := d.Next(); err != io.EOF {
+		t.Errorf("Decoder.Next() = %v, %v, want io.EOF", got, err)
+	}
+	if len(failed) != 2 || failed[0].Issue != "Malformed sentence" || failed[1].Issue != "Malformed sentence" {
+		t.Errorf("Decoder.Failed got %v", failed)
+	}
+}
+
+type errorReader struct{}
+
+func (errorReader) Read(p []byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestDecoderErrors(t *testing.T) {
+	d := NewDecoder(context.Background(), errorReader{}, RouterOptions{})
+	if _, err := d.Next(); err == nil || err.Error() != "connection reset" {
+		t.Errorf("Decoder.Next() = %v, want the read error", err)
+	}
+
+	// A reader that never returns data
+	r, w := io.Pipe()
+	defer w.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	d = NewDecoder(ctx, r, RouterOptions{})
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := d.Next(); err != context.Canceled {
+		t.Errorf("Decoder.Next() = %v, want context.Canceled", err)
+	}
+}
+
+func BenchmarkDecoder(b *testing.B) {
+	input := strings.Repeat("!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F\r\n", b.N)
+	d := NewDecoder(context.Background(), strings.NewReader(input), RouterOptions{})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.Next()
+	}
+}
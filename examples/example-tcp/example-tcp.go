@@ -18,15 +18,12 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
+	"flag"
 	"fmt"
 	"log"
-	"net"
 	"net/http"
-	"strconv"
 	"time"
 
 	ais "github.com/andmarios/aislib"
@@ -36,11 +33,13 @@ import (
 var serveJSON string
 
 type shipData struct {
-	Data  ais.ClassAPositionReport
+	Data  ais.Vessel
 	Human string
 }
 
 func main() {
+	remote := flag.String("remote", "ais1.shipraiser.net:6492", "address of the AIS server")
+	flag.Parse()
 
 	// Create an AIS router process to decode radio sentences
 	send := make(chan string, 1024)
@@ -48,81 +47,45 @@ func main() {
 	failed := make(chan ais.FailedSentence, 1024)
 	go ais.Router(send, receive, failed)
 
-	// Create a handler-process that reads messages from router, decodes and saves the payload
-	seen := make(map[uint32]shipData)
-	proceed := make(chan bool)
+	// Create a handler-process that reads messages from router and merges them into a registry
+	registry := ais.NewRegistry()
 	go func() {
-		var message ais.Message
-		var problematic ais.FailedSentence
 		for {
 			select {
-			case message = <-receive:
-				if message.Type >= 1 && message.Type <= 3 {
-					m, _ := ais.DecodeClassAPositionReport(message.Payload)
-					seen[m.MMSI] = shipData{m, m.String()}
-				}
-			case problematic = <-failed:
+			case message := <-receive:
+				registry.Update(message)
+			case problematic := <-failed:
 				log.Println(problematic)
-			case _ = <-proceed: // Unbuffered channel used for synchronization (as mutex for [seen])
-				<-proceed
 			}
 		}
 	}()
 
-	// Create a process that every five seconds refreshes [serveJSON] with new data
+	// Create a process that every five seconds refreshes [serveJSON] with the ships seen in the
+	// last period and forgets the ships not seen for an hour
 	go func() {
-		var jsonBuf bytes.Buffer
 		for _ = range time.Tick(5 * time.Second) {
-			proceed <- true
-			ships := seen
-			seen = make(map[uint32]shipData)
-			proceed <- true
-			for _, s := range ships {
-				j, _ := json.Marshal(s)
-				jsonBuf.Write(j)
-				jsonBuf.WriteString(",")
-			}
-			length := len(jsonBuf.String())
-			if length > 10 {
-				serveJSON = "[" + jsonBuf.String()[:length-1] + "]"
-				jsonBuf.Reset()
-			} else {
-				serveJSON = "[]"
+			ships := []shipData{}
+			for _, v := range registry.Vessels(time.Now().Add(-5 * time.Second)) {
+				if !v.PositionTime.IsZero() {
+					ships = append(ships, shipData{v, v.String()})
+				}
 			}
+			j, _ := json.Marshal(ships)
+			serveJSON = string(j)
+			registry.Expire(time.Now().Add(-time.Hour))
 		}
 	}()
 
 	// Connect to a remote AIS server. Read AIS sentences and forward them to the AIS router.
-	// If connection drops wait and reconnect.
-	remote := "ais1.shipraiser.net:6492"
-	go func() {
-		sleep := 10 // How many seconds to sleep after a timeout
-		sleepD := time.Duration(sleep) * time.Second
-		for {
-			serverAddr, err := net.ResolveTCPAddr("tcp", remote)
-			if err != nil {
-				log.Println(err, errors.New("(retrying in "+strconv.Itoa(sleep)+" seconds)"))
-				time.Sleep(sleepD)
-				continue
-			}
-			conn, err := net.DialTCP("tcp", nil, serverAddr)
-			if err != nil {
-				log.Println(err, errors.New("(retrying in "+strconv.Itoa(sleep)+" seconds)"))
-				time.Sleep(sleepD)
-				continue
-			}
-			defer conn.Close()
-
-			connbuf := bufio.NewScanner(conn)
-			connbuf.Split(bufio.ScanLines)
-			for connbuf.Scan() {
-				send <- connbuf.Text()
-				conn.SetReadDeadline(time.Now().Add(15 * time.Second))
-			}
-			log.Println(remote + ": connection broken (retrying in " + strconv.Itoa(sleep) + " seconds)")
-			time.Sleep(sleepD)
+	// If connection drops the client waits and reconnects.
+	client := ais.NewTCPClient(*remote)
+	client.ReadTimeout = 15 * time.Second
+	client.OnStateChange = func(address string, state ais.SourceState, err error) {
+		if err != nil {
+			log.Println(address+":", state, err)
 		}
-	}()
+	}
+	go client.Run(context.Background(), send)
 
 	// Create a server to listen for files/data requests
 	http.HandleFunc("/data", dataHandler)
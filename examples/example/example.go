@@ -18,8 +18,9 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 
@@ -27,54 +28,41 @@ import (
 )
 
 func main() {
-	in := bufio.NewScanner(os.Stdin)
-	in.Split(bufio.ScanLines)
-
-	send := make(chan string, 1024*8)
-	receive := make(chan ais.Message, 1024*8)
-	failed := make(chan ais.FailedSentence, 1024*8)
-
-	done := make(chan bool)
-
-	go ais.Router(send, receive, failed)
+	in := ais.NewDecoder(context.Background(), os.Stdin, ais.RouterOptions{})
+	in.Failed = func(problematic ais.FailedSentence) {
+		log.Println(problematic)
+	}
 
-	go func() {
-		var message ais.Message
-		var problematic ais.FailedSentence
-		for {
-			select {
-			case message = <-receive:
-				switch message.Type {
-				case 1, 2, 3:
-					t, _ := ais.DecodeClassAPositionReport(message.Payload)
-					fmt.Println(t)
-				case 4:
-					t, _ := ais.DecodeBaseStationReport(message.Payload)
-					fmt.Println(t)
-				case 5:
-					t, _ := ais.DecodeStaticVoyageData(message.Payload)
-					fmt.Println(t)
-				case 8:
-					t, _ := ais.DecodeBinaryBroadcast(message.Payload)
-					fmt.Println(t)
-				case 18:
-					t, _ := ais.DecodeClassBPositionReport(message.Payload)
-					fmt.Println(t)
-				case 255:
-					done <- true
-				default:
-					fmt.Printf("=== Message Type %2d ===\n", message.Type)
-					fmt.Printf(" Unsupported type \n\n")
-				}
-			case problematic = <-failed:
-				log.Println(problematic)
+	for {
+		message, err := in.Next()
+		if err != nil {
+			if err != io.EOF {
+				log.Println(err)
 			}
+			return
+		}
+		switch message.Type {
+		case 1, 2, 3:
+			t, _ := ais.DecodeClassAPositionReport(message.Payload)
+			fmt.Println(t)
+		case 4:
+			t, _ := ais.DecodeBaseStationReport(message.Payload)
+			fmt.Println(t)
+		case 5:
+			t, _ := ais.DecodeStaticVoyageData(message.Payload)
+			fmt.Println(t)
+		case 6:
+			t, _ := ais.DecodeAddressedBinaryPadded(message.Payload, message.Padding)
+			fmt.Println(t)
+		case 8:
+			t, _ := ais.DecodeBinaryBroadcastPadded(message.Payload, message.Padding)
+			fmt.Println(t)
+		case 18:
+			t, _ := ais.DecodeClassBPositionReport(message.Payload)
+			fmt.Println(t)
+		default:
+			fmt.Printf("=== Message Type %2d ===\n", message.Type)
+			fmt.Printf(" Unsupported type \n\n")
 		}
-	}()
-
-	for in.Scan() {
-		send <- in.Text()
 	}
-	close(send)
-	<-done
 }
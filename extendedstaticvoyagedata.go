@@ -0,0 +1,168 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"errors"
+)
+
+// ExtendedVoyageData are the fields of the Extended Ship Static and Voyage Related Data
+// messages. Fields with zero values are not available.
+type ExtendedVoyageData struct {
+	AirDraught      uint16    // Meters/10
+	LastPort        string    // UN/LOCODE of last port of call
+	NextPort        string    // UN/LOCODE of next port of call
+	SecondNextPort  string    // UN/LOCODE of second next port of call
+	EquipmentStatus [26]uint8 // Status of SOLAS equipment, 0: not available, 1: operational, 2: not operational
+	IceClass        uint8     // enumerated
+	ShaftPower      uint32    // Horse power
+	VHFChannel      uint16    // VHF working channel
+	LloydsShipType  string    // Lloyd's ship type
+	GrossTonnage    uint32
+	Laden           uint8  // 0: not available, 1: laden, 2: ballast
+	HeavyFuelOil    uint8  // 0: not available, 1: no, 2: yes
+	LightFuelOil    uint8  // 0: not available, 1: no, 2: yes
+	Diesel          uint8  // 0: not available, 1: no, 2: yes
+	BunkerOil       uint16 // Total bunker oil in tonnes
+	PersonsOnBoard  uint16
+}
+
+// ExtendedStaticVoyageData is a DAC 1, FID 15 (IMO 236) or FID 24 (IMO 289) message.
+// FID 15 carries only the air draught.
+type ExtendedStaticVoyageData struct {
+	BinaryHeader
+	LinkageID uint16 // Message linkage ID, FID 24 only
+	ExtendedVoyageData
+}
+
+// DecodeExtendedStaticVoyageData decodes the payload of an Extended Ship Static and Voyage
+// Related Data message (type 8 DAC 1 FID 15 or 24).
+func DecodeExtendedStaticVoyageData(payload string) (ExtendedStaticVoyageData, error) {
+	r := NewBitReader(payload)
+	var m ExtendedStaticVoyageData
+
+	m.BinaryHeader, _ = decodeBinaryHeader(r)
+	if m.Type != 8 || m.DAC != 1 || (m.FID != 15 && m.FID != 24) {
+		return m, errors.New("Message isn't Extended Static and Voyage Related Data (type 8, DAC 1, FID 15 or 24).")
+	}
+
+	if m.FID == 15 {
+		m.AirDraught = uint16(r.Uint(56, 66))
+		return m, nil
+	}
+
+	m.LinkageID = uint16(r.Uint(56, 65))
+	m.AirDraught = uint16(r.Uint(66, 78))
+
+	m.LastPort = r.String(79, 108)
+	m.NextPort = r.String(109, 138)
+	m.SecondNextPort = r.String(139, 168)
+
+	for i := range m.EquipmentStatus {
+		m.EquipmentStatus[i] = uint8(r.Uint(169+i*2, 170+i*2))
+	}
+
+	m.IceClass = uint8(r.Uint(221, 224))
+	m.ShaftPower = uint32(r.Uint(225, 242))
+	m.VHFChannel = uint16(r.Uint(243, 254))
+	m.LloydsShipType = r.String(255, 296)
+	m.GrossTonnage = uint32(r.Uint(297, 314))
+
+	m.Laden = uint8(r.Uint(315, 316))
+	m.HeavyFuelOil = uint8(r.Uint(317, 318))
+	m.LightFuelOil = uint8(r.Uint(319, 320))
+	m.Diesel = uint8(r.Uint(321, 322))
+	m.BunkerOil = uint16(r.Uint(323, 336))
+
+	m.PersonsOnBoard = uint16(r.Uint(337, 349))
+
+	return m, nil
+}
+
+// StaticVoyageRecord keeps the static and voyage related data of a vessel, as sent in type 5
+// and extended static and voyage related data messages.
+type StaticVoyageRecord struct {
+	StaticVoyageData
+	ExtendedVoyageData
+}
+
+// Merge updates the record with the available fields of an extended static and voyage related
+// data message. Fields that the message doesn't provide keep their previous value.
+func (r *StaticVoyageRecord) Merge(e ExtendedStaticVoyageData) error {
+	if r.MMSI != 0 && r.MMSI != e.MMSI {
+		return errors.New("Extended static and voyage data belong to another vessel.")
+	}
+	r.MMSI = e.MMSI
+
+	if e.AirDraught != 0 {
+		r.AirDraught = e.AirDraught
+	}
+	if e.FID == 15 { // Carries only the air draught
+		return nil
+	}
+
+	if e.LastPort != "" {
+		r.LastPort = e.LastPort
+	}
+	if e.NextPort != "" {
+		r.NextPort = e.NextPort
+	}
+	if e.SecondNextPort != "" {
+		r.SecondNextPort = e.SecondNextPort
+	}
+	for i, s := range e.EquipmentStatus {
+		if s != 0 {
+			r.EquipmentStatus[i] = s
+		}
+	}
+	if e.IceClass != 0 {
+		r.IceClass = e.IceClass
+	}
+	if e.ShaftPower != 0 {
+		r.ShaftPower = e.ShaftPower
+	}
+	if e.VHFChannel != 0 {
+		r.VHFChannel = e.VHFChannel
+	}
+	if e.LloydsShipType != "" {
+		r.LloydsShipType = e.LloydsShipType
+	}
+	if e.GrossTonnage != 0 {
+		r.GrossTonnage = e.GrossTonnage
+	}
+	if e.Laden != 0 {
+		r.Laden = e.Laden
+	}
+	if e.HeavyFuelOil != 0 {
+		r.HeavyFuelOil = e.HeavyFuelOil
+	}
+	if e.LightFuelOil != 0 {
+		r.LightFuelOil = e.LightFuelOil
+	}
+	if e.Diesel != 0 {
+		r.Diesel = e.Diesel
+	}
+	if e.BunkerOil != 0 {
+		r.BunkerOil = e.BunkerOil
+	}
+	if e.PersonsOnBoard != 0 {
+		r.PersonsOnBoard = e.PersonsOnBoard
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDecodeExtendedStaticVoyageData(t *testing.T) {
+	status := [26]uint8{1, 1, 1, 2, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 0}
+	cases := []struct {
+		payload string
+		want    ExtendedStaticVoyageData
+	}{
+		{
+			"83uJur00CjL0",
+			ExtendedStaticVoyageData{
+				BinaryHeader:       BinaryHeader{Type: 8, Repeat: 0, MMSI: 265731560, DAC: 1, FID: 15},
+				ExtendedVoyageData: ExtendedVoyageData{AirDraught: 312},
+			},
+		},
+		{
+			"83uJur00F0<3SaRSWb25PPa00000:jbbbbbb`@3W@22R`p00000HLd0601@0",
+			ExtendedStaticVoyageData{
+				BinaryHeader: BinaryHeader{Type: 8, Repeat: 0, MMSI: 265731560, DAC: 1, FID: 24},
+				LinkageID:    12,
+				ExtendedVoyageData: ExtendedVoyageData{
+					AirDraught: 455, LastPort: "SEGOT", NextPort: "DKAAR", SecondNextPort: "",
+					EquipmentStatus: status, IceClass: 2, ShaftPower: 1850, VHFChannel: 16,
+					LloydsShipType: "TUG", GrossTonnage: 195, Laden: 2, HeavyFuelOil: 1,
+					LightFuelOil: 1, Diesel: 2, BunkerOil: 12, PersonsOnBoard: 5,
+				},
+			},
+		},
+	}
+	for _, c := range cases {
+		got, _ := DecodeExtendedStaticVoyageData(c.payload)
+		if got != c.want {
+			fmt.Println("Got : ", got)
+			fmt.Println("Want: ", c.want)
+			t.Errorf("DecodeExtendedStaticVoyageData(payload string)")
+		}
+	}
+}
+
+func TestStaticVoyageRecordMerge(t *testing.T) {
+	var r StaticVoyageRecord
+	r.StaticVoyageData, _ = DecodeStaticVoyageData("53uJur01rN?U<9@T001@tI@F000000000000000l0pA444mm?:1km1@SlQp000000000000")
+
+	fid24, _ := DecodeExtendedStaticVoyageData("83uJur00F0<3SaRSWb25PPa00000:jbbbbbb`@3W@22R`p00000HLd0601@0")
+	fid15, _ := DecodeExtendedStaticVoyageData("83uJur00CjL0")
+	if err := r.Merge(fid24); err != nil {
+		t.Errorf("StaticVoyageRecord.Merge(e ExtendedStaticVoyageData): %s", err)
+	}
+	if err := r.Merge(fid15); err != nil {
+		t.Errorf("StaticVoyageRecord.Merge(e ExtendedStaticVoyageData): %s", err)
+	}
+
+	if r.VesselName != "TOFTE" || r.AirDraught != 312 || r.LastPort != "SEGOT" || r.NextPort != "DKAAR" ||
+		r.GrossTonnage != 195 {
+		fmt.Println("Got : ", r)
+		t.Errorf("StaticVoyageRecord.Merge(e ExtendedStaticVoyageData)")
+	}
+
+	var other StaticVoyageRecord
+	other.MMSI = 257556700
+	if err := other.Merge(fid24); err == nil {
+		t.Errorf("StaticVoyageRecord.Merge(e ExtendedStaticVoyageData) merged another vessel's data")
+	}
+}
@@ -17,8 +17,6 @@
 
 package aislib
 
-import "strings"
-
 // decodeAisChar takes a byte a returns the six bit field of AIS data.
 func decodeAisChar(character byte) byte {
 	character -= 48
@@ -34,91 +32,52 @@ func MessageType(payload string) uint8 {
 	return decodeAisChar(data[0])
 }
 
-// bitsToInt extracts certain bits from a payload.
-// Payload consists of six bit packets, each one armored in one byte.
-// The function seems simple enough but took me some hours to figure out.
-// It is necessary since this is the most frequent part of the message decoding process
-// and one can only write so many binary operations. We sacrifice performance a bit to
-// significantly ease development. :-/
-func bitsToInt(first, last int, payload []byte) uint32 {
-	size := uint(last - first) // Bit fields start at 0
-	processed, remain := uint(0), uint(0)
-	result, temp := uint32(0), uint32(0)
-
-	from := first / 6
-	forTimes := last/6 - from
+// MessageMMSI returns the MMSI of the station that sent an AIS message, or 0 if the payload is
+// too short.
+func MessageMMSI(payload string) uint32 {
+	return uint32(NewBitReader(payload).Uint(8, 37))
+}
 
-	if len(payload)*6 < last+1 { // There are strange messages out there, this seems to be what decoders do
-		return 0
+// MessagePosition returns the coordinates in decimal degrees reported by a message of type
+// 1, 2, 3, 4, 9, 11, 18, 19, 21 or 27. The last value is false for other types, short payloads
+// and messages without an available position.
+func MessagePosition(payload string) (float64, float64, bool) {
+	if len(payload) == 0 {
+		return 0, 0, false
 	}
-	for i := 0; i <= forTimes; i++ {
-		// Instead of calling decodeAisChar we do the calculation manually here for speed.
-		temp = uint32(payload[from+i]) - 48
-		if temp > 40 {
-			temp -= 8
+	r := NewBitReader(payload)
+	var lon, lat float64
+	switch MessageType(payload) {
+	case 1, 2, 3, 9:
+		if r.Len() < 116 {
+			return 0, 0, false
 		}
-
-		// Depending on which byte in sequence we processing, do the appropriate shifts.
-		if i == 0 { // For the first byte we (may) have to clean leftmost bits and shift to position
-			remain = uint(first % 6)
-			processed = 5 - remain
-			temp = temp << (31 - processed) >> (31 - size)
-		} else if i < forTimes { // For middle bytes we only shift to position
-			processed = processed + 6
-			temp = temp << (size - processed)
-		} else { // For last byte we (may) clear rightmost bits
-			remain = uint(last%6) + 1
-			temp = temp >> (6 - remain)
+		lon, lat = cbnCoordinates(61, r)
+	case 4, 11:
+		if r.Len() < 134 {
+			return 0, 0, false
 		}
-		result = result | temp
-	}
-	return result
-}
-
-// bitsToString decodes text from an AIS payload. Text is packed in six bit ASCII
-func bitsToString(first, last int, payload []byte) string {
-	length := (last - first + 1) / 6 // How many characters we expect
-	start := first / 6               // At which byte the first character starts
-	var text [64]byte                // Not sure which the maximum text field size is, but this should be enough
-	char := uint8(0)
-
-	// Some times we get truncated text fields. Since text fields have constant size,
-	// it is frequent that they aren't fully occupied. Transmitters use this to send shorter messages.
-	// We should handle this gracefully, adjusting the length of the text we expect to read.
-	if len(payload)*6 < last+1 {
-		if len(payload)*6 < first+5 { // Haven't seen this case yet (text field missing) but better be prepared
-			return ""
+		lon, lat = cbnCoordinates(79, r)
+	case 18, 19:
+		if r.Len() < 112 {
+			return 0, 0, false
 		}
-		// Do not simplify this. It uses the uint type rounding method to get correct results
-		length = (len(payload)*6 - first) / 6
-	}
-
-	remain := first % 6
-
-	// In this if/else there is some code duplication but I think the speed enhancement is worth it.
-	// The other way around would need 2*length branches. Now we have only 2.
-	// decodeAisChar function should be safe to use here since we check the payload's length
-	if remain < 6 {
-		shiftLeftMost := uint8(remain + 2)
-		shiftRightMost := uint8(6 - remain)
-		for i := 0; i < length; i++ {
-			char = decodeAisChar(payload[start+i])<<shiftLeftMost>>2 |
-				decodeAisChar(payload[start+i+1])>>shiftRightMost
-			if char < 32 {
-				char += 64
-			}
-			text[i] = char
+		lon, lat = cbnCoordinates(57, r)
+	case 21:
+		if r.Len() < 219 {
+			return 0, 0, false
 		}
-	} else {
-		for i := 0; i < length; i++ {
-			char = decodeAisChar(payload[start+i])
-			if char < 32 {
-				char += 64
-			}
-			text[i] = char
+		lon, lat = cbnCoordinates(164, r)
+	case 27: // 1/10 minutes
+		if r.Len() < 79 {
+			return 0, 0, false
 		}
+		lon, lat = CoordinatesMin2Deg(float64(r.Int(44, 61))*1000, float64(r.Int(62, 78))*1000)
+	default:
+		return 0, 0, false
 	}
-
-	// We convert to string and trim the righmost spaces and @ according to the format specs.
-	return strings.TrimRight(string(text[:length]), "@ ")
+	if lon < -180 || lon > 180 || lat < -90 || lat > 90 {
+		return 0, 0, false
+	}
+	return lon, lat, true
 }
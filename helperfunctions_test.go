@@ -0,0 +1,69 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMessageMMSI(t *testing.T) {
+	cases := []struct {
+		payload string
+		want    uint32
+	}{
+		{"38u<a<?PAA2>P:WfuAO9PW<P0PuQ", 601041200},
+		{"B3ujWF0000DdVU8O:1H03wi5oP06", 266119000},
+		{"38u<a", 0},
+	}
+	for _, c := range cases {
+		if got := MessageMMSI(c.payload); got != c.want {
+			t.Errorf("MessageMMSI(%q) = %d, want %d", c.payload, got, c.want)
+		}
+	}
+}
+
+func TestMessagePosition(t *testing.T) {
+	unavailable, _ := EncodeClassAPositionReport(ClassAPositionReport{
+		PositionReport: PositionReport{Type: 1, MMSI: 1, Lon: 181, Lat: 91}})
+	w := NewBitWriter(96) // Type 27, 1/10 minutes
+	w.Uint(0, 5, 27)
+	w.Int(44, 61, 2340)
+	w.Int(62, 78, -1234)
+	long, _ := w.Payload()
+
+	cases := []struct {
+		payload  string
+		lon, lat float64
+		ok       bool
+	}{
+		{"38u<a<?PAA2>P:WfuAO9PW<P0PuQ", 31.130165, -29.784113333333334, true},
+		{"B3ujWF0000DdVU8O:1H03wi5oP06", 18.085243333333334, 59.32718333333333, true},
+		{long, 3.9, -2.0566666666666666, true},
+		{unavailable.Payload, 0, 0, false},
+		{"533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H51CU0E2CkP0", 0, 0, false},
+		{"38u<a<?PAA2", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, c := range cases {
+		lon, lat, ok := MessagePosition(c.payload)
+		if ok != c.ok || math.Abs(lon-c.lon) > 1e-9 || math.Abs(lat-c.lat) > 1e-9 {
+			t.Errorf("MessagePosition(%q) = %f, %f, %t, want %f, %f, %t", c.payload, lon, lat, ok, c.lon, c.lat, c.ok)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Inland AIS (DAC 200) messages are used on the European inland waterways.
+
+// InlandStaticVoyageData is a DAC 200, FID 10 message. It complements the Static
+// Voyage Data (type 5) of inland vessels.
+type InlandStaticVoyageData struct {
+	BinaryHeader
+	ENI            string // European Vessel Identification Number
+	Length         uint16 // Meters/10
+	Beam           uint16 // Meters/10
+	ShipType       uint16 // ERI ship or combination type (see InlandShipType)
+	HazardousCargo uint8  // Number of blue cones/lights (see InlandHazardousCargo)
+	Draught        uint16 // Meters/100
+	Loaded         uint8  // 0: not available, 1: loaded, 2: unloaded
+	SpeedQuality   bool   // true if speed comes from a certified device
+	CourseQuality  bool   // true if course comes from a certified device
+	HeadingQuality bool   // true if heading comes from a certified device
+}
+
+// EMMAWarning is a DAC 200, FID 23 message. It carries a weather warning of the European
+// Multiservice Meteorological Awareness system for a fairway section.
+type EMMAWarning struct {
+	BinaryHeader
+	Start          time.Time // Year is given as 20XX
+	End            time.Time
+	StartLon       float64 // Fairway section start
+	StartLat       float64
+	EndLon         float64 // Fairway section end
+	EndLat         float64
+	WeatherType    uint8 // enumerated, see EMMAWeatherTypes
+	Min            int16 // Minimum value, unit depends on the weather type
+	Max            int16 // Maximum value, unit depends on the weather type
+	Classification uint8 // 0: unknown, 1: slight, 2: medium, 3: strong/heavy
+	WindDirection  uint8 // enumerated, see EMMAWindDirections
+}
+
+// WaterLevelGauge is a gauge reading of a Water Level report. A gauge with ID 0 is unused.
+type WaterLevelGauge struct {
+	ID    uint16
+	Level int16 // Meters/100
+}
+
+// InlandWaterLevels is a DAC 200, FID 24 message.
+type InlandWaterLevels struct {
+	BinaryHeader
+	Country string // UN country code
+	Gauges  [4]WaterLevelGauge
+}
+
+// InlandSignalStatus is a DAC 200, FID 40 message. It reports the status of a signal
+// (e.g at a lock or a bridge).
+type InlandSignalStatus struct {
+	BinaryHeader
+	Lon         float64
+	Lat         float64
+	Form        uint8     // Signal form
+	Orientation uint16    // Orientation of the signal in degrees
+	Impact      uint8     // Direction of impact, enumerated, see SignalImpactDirections
+	Lights      [10]uint8 // Status of each light, enumerated, see SignalLightStatus
+}
+
+// DecodeInlandStaticVoyageData decodes the payload of an Inland ship static and voyage
+// related data message (type 8 DAC 200 FID 10).
+func DecodeInlandStaticVoyageData(payload string) (InlandStaticVoyageData, error) {
+	r := NewBitReader(payload)
+	var m InlandStaticVoyageData
+
+	m.BinaryHeader, _ = decodeBinaryHeader(r)
+	if m.Type != 8 || m.DAC != 200 || m.FID != 10 {
+		return m, errors.New("Message isn't Inland Static and Voyage Related Data (type 8, DAC 200, FID 10).")
+	}
+
+	m.ENI = r.String(56, 103)
+
+	m.Length = uint16(r.Uint(104, 116))
+	m.Beam = uint16(r.Uint(117, 126))
+
+	m.ShipType = uint16(r.Uint(127, 140))
+	m.HazardousCargo = uint8(r.Uint(141, 143))
+
+	m.Draught = uint16(r.Uint(144, 154))
+	m.Loaded = uint8(r.Uint(155, 156))
+
+	m.SpeedQuality = r.Bool(157)
+	m.CourseQuality = r.Bool(158)
+	m.HeadingQuality = r.Bool(159)
+
+	return m, nil
+}
+
+// DecodeEMMAWarning decodes the payload of an EMMA warning report (type 8 DAC 200 FID 23).
+func DecodeEMMAWarning(payload string) (EMMAWarning, error) {
+	r := NewBitReader(payload)
+	var m EMMAWarning
+
+	m.BinaryHeader, _ = decodeBinaryHeader(r)
+	if m.Type != 8 || m.DAC != 200 || m.FID != 23 {
+		return m, errors.New("Message isn't EMMA Warning Report (type 8, DAC 200, FID 23).")
+	}
+
+	// Dates come first, then times
+	timeString := fmt.Sprintf("%d/%d/%d %d:%d", 2000+r.Uint(56, 63), r.Uint(64, 67),
+		r.Uint(68, 72), r.Uint(90, 94), r.Uint(95, 100))
+	m.Start, _ = time.Parse("2006/1/2 15:4", timeString)
+	timeString = fmt.Sprintf("%d/%d/%d %d:%d", 2000+r.Uint(73, 80), r.Uint(81, 84),
+		r.Uint(85, 89), r.Uint(101, 105), r.Uint(106, 111))
+	m.End, _ = time.Parse("2006/1/2 15:4", timeString)
+
+	m.StartLon, m.StartLat = cbnCoordinates(112, r)
+	m.EndLon, m.EndLat = cbnCoordinates(167, r)
+
+	m.WeatherType = uint8(r.Uint(222, 225))
+	m.Min = int16(r.Int(226, 234))
+	m.Max = int16(r.Int(235, 243))
+	m.Classification = uint8(r.Uint(244, 245))
+	m.WindDirection = uint8(r.Uint(246, 249))
+
+	return m, nil
+}
+
+// DecodeInlandWaterLevels decodes the payload of a Water Levels message (type 8 DAC 200 FID 24).
+func DecodeInlandWaterLevels(payload string) (InlandWaterLevels, error) {
+	r := NewBitReader(payload)
+	var m InlandWaterLevels
+
+	m.BinaryHeader, _ = decodeBinaryHeader(r)
+	if m.Type != 8 || m.DAC != 200 || m.FID != 24 {
+		return m, errors.New("Message isn't Inland Water Levels (type 8, DAC 200, FID 24).")
+	}
+
+	m.Country = r.String(56, 67)
+
+	// Four gauges, 25 bits each
+	for i := range m.Gauges {
+		first := 68 + i*25
+		m.Gauges[i].ID = uint16(r.Uint(first, first+10))
+		m.Gauges[i].Level = int16(r.Int(first+11, first+24))
+	}
+
+	return m, nil
+}
+
+// DecodeInlandSignalStatus decodes the payload of a Signal Status message (type 8 DAC 200 FID 40).
+func DecodeInlandSignalStatus(payload string) (InlandSignalStatus, error) {
+	r := NewBitReader(payload)
+	var m InlandSignalStatus
+
+	m.BinaryHeader, _ = decodeBinaryHeader(r)
+	if m.Type != 8 || m.DAC != 200 || m.FID != 40 {
+		return m, errors.New("Message isn't Inland Signal Status (type 8, DAC 200, FID 40).")
+	}
+
+	m.Lon, m.Lat = cbnCoordinates(56, r)
+
+	m.Form = uint8(r.Uint(111, 114))
+	m.Orientation = uint16(r.Uint(115, 123))
+	m.Impact = uint8(r.Uint(124, 126))
+
+	// Ten lights, 3 bits each
+	for i := range m.Lights {
+		m.Lights[i] = uint8(r.Uint(127+i*3, 129+i*3))
+	}
+
+	return m, nil
+}
+
+var InlandHazardousCargo = [...]string{
+	"0 blue cones/lights", "1 blue cone/light", "2 blue cones/lights", "3 blue cones/lights",
+	"B-Flag", "Unknown", "not defined", "not defined",
+}
+
+var EMMAWeatherTypes = [...]string{
+	"Default", "Wind", "Rain", "Snow and ice", "Thunderstorm", "Fog", "Low temperature",
+	"High temperature", "Flood", "Fire in the forests",
+	"not defined", "not defined", "not defined", "not defined", "not defined", "not defined",
+}
+
+var EMMAWindDirections = [...]string{
+	"Unknown", "North", "North east", "East", "South east", "South", "South west", "West",
+	"North west", "not defined", "not defined", "not defined", "not defined", "not defined",
+	"not defined", "not defined",
+}
+
+var SignalImpactDirections = [...]string{
+	"Unknown", "Upstream", "Downstream", "To left bank", "To right bank",
+	"not defined", "not defined", "not defined",
+}
+
+var SignalLightStatus = [...]string{
+	"Not available", "White", "Yellow", "Red", "Green", "Blue", "not defined", "not defined",
+}
+
+// InlandShipType are the ERI ship and combination types used by Inland AIS.
+var InlandShipType = map[int]string{
+	1500: "General cargo vessel maritime",
+	1510: "Unit carrier maritime",
+	1520: "Bulk carrier maritime",
+	1530: "Tanker",
+	1540: "Liquefied gas tanker",
+	1850: "Pleasure craft, longer than 20 metres",
+	1900: "Fast ship",
+	1910: "Hydrofoil",
+	1920: "Catamaran fast",
+	8000: "Vessel, type unknown",
+	8010: "Motor freighter",
+	8020: "Motor tanker",
+	8021: "Motor tanker, liquid cargo, type N",
+	8022: "Motor tanker, liquid cargo, type C",
+	8023: "Motor tanker, dry cargo as if liquid (e.g. cement)",
+	8030: "Container vessel",
+	8040: "Gas tanker",
+	8050: "Motor freighter, tug",
+	8060: "Motor tanker, tug",
+	8070: "Motor freighter with one or more ships alongside",
+	8080: "Motor freighter with tanker",
+	8090: "Motor freighter pushing one or more freighters",
+	8100: "Motor freighter pushing at least one tank-ship",
+	8110: "Tug, freighter",
+	8120: "Tug, tanker",
+	8130: "Tug freighter, coupled",
+	8140: "Tug, freighter/tanker, coupled",
+	8150: "Freightbarge",
+	8160: "Tankbarge",
+	8161: "Tankbarge, liquid cargo, type N",
+	8162: "Tankbarge, liquid cargo, type C",
+	8163: "Tankbarge, dry cargo as if liquid (e.g. cement)",
+	8170: "Freightbarge with containers",
+	8180: "Tankbarge, gas",
+	8210: "Pushtow, one cargo barge",
+	8220: "Pushtow, two cargo barges",
+	8230: "Pushtow, three cargo barges",
+	8240: "Pushtow, four cargo barges",
+	8250: "Pushtow, five cargo barges",
+	8260: "Pushtow, six cargo barges",
+	8270: "Pushtow, seven cargo barges",
+	8280: "Pushtow, eight cargo barges",
+	8290: "Pushtow, nine or more barges",
+	8310: "Pushtow, one tank/gas barge",
+	8320: "Pushtow, two barges at least one tanker or gas barge",
+	8330: "Pushtow, three barges at least one tanker or gas barge",
+	8340: "Pushtow, four barges at least one tanker or gas barge",
+	8350: "Pushtow, five barges at least one tanker or gas barge",
+	8360: "Pushtow, six barges at least one tanker or gas barge",
+	8370: "Pushtow, seven barges at least one tanker or gas barge",
+	8380: "Pushtow, eight barges at least one tanker or gas barge",
+	8390: "Pushtow, nine or more barges at least one tanker or gas barge",
+	8400: "Tug, single",
+	8410: "Tug, one or more tows",
+	8420: "Tug, assisting a vessel or linked combination",
+	8430: "Pushboat, single",
+	8440: "Passenger ship, ferry, cruise ship, red cross ship",
+	8441: "Ferry",
+	8442: "Red cross ship",
+	8443: "Cruise ship",
+	8444: "Passenger ship without accommodation",
+	8450: "Service vessel, police patrol, port service",
+	8460: "Vessel, work maintenance craft, floating derrick, cable-ship, buoy-ship, dredge",
+	8470: "Object, towed, not otherwise specified",
+	8480: "Fishing boat",
+	8490: "Bunkership",
+	8500: "Barge, tanker, chemical",
+	8510: "Object, not otherwise specified",
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDecodeInlandStaticVoyageData(t *testing.T) {
+	want := InlandStaticVoyageData{
+		BinaryHeader: BinaryHeader{Type: 8, Repeat: 0, MMSI: 211512340, DAC: 200, FID: 10},
+		ENI:          "04801650", Length: 1105, Beam: 114, ShipType: 8020, HazardousCargo: 2,
+		Draught: 280, Loaded: 1, SpeedQuality: true, CourseQuality: false, HeadingQuality: true,
+	}
+	got, _ := DecodeInlandStaticVoyageData("839ed50j2d=><<MeL2:8q?bR8hl0")
+	if got != want {
+		fmt.Println("Got : ", got)
+		fmt.Println("Want: ", want)
+		t.Errorf("DecodeInlandStaticVoyageData(payload string)")
+	}
+}
+
+func TestDecodeEMMAWarning(t *testing.T) {
+	want := EMMAWarning{
+		BinaryHeader: BinaryHeader{Type: 8, Repeat: 0, MMSI: 2112345, DAC: 200, FID: 23},
+		Start:        time.Date(2026, 3, 14, 18, 30, 0, 0, time.UTC),
+		End:          time.Date(2026, 3, 15, 6, 0, 0, 0, time.UTC),
+		StartLon:     6.95, StartLat: 50.93, EndLon: 7.1, EndLat: 51.05,
+		WeatherType: 1, Min: -12, Max: 45, Classification: 2, WindDirection: 6,
+	}
+	got, _ := DecodeEMMAWarning("8020sF@j5i`o3AgTtH03vQ43a8qP220D1ln1h7r2nH0")
+	if got != want {
+		fmt.Println("Got : ", got)
+		fmt.Println("Want: ", want)
+		t.Errorf("DecodeEMMAWarning(payload string)")
+	}
+}
+
+func TestDecodeInlandWaterLevels(t *testing.T) {
+	want := InlandWaterLevels{
+		BinaryHeader: BinaryHeader{Type: 8, Repeat: 0, MMSI: 2112345, DAC: 200, FID: 24},
+		Country:      "DE",
+		Gauges:       [4]WaterLevelGauge{{1201, 312}, {1202, -45}},
+	}
+	got, _ := DecodeInlandWaterLevels("8020sF@j611IHPW4dgu<00000000")
+	if got != want {
+		fmt.Println("Got : ", got)
+		fmt.Println("Want: ", want)
+		t.Errorf("DecodeInlandWaterLevels(payload string)")
+	}
+}
+
+func TestDecodeInlandSignalStatus(t *testing.T) {
+	want := InlandSignalStatus{
+		BinaryHeader: BinaryHeader{Type: 8, Repeat: 0, MMSI: 2112345, DAC: 200, FID: 40},
+		Lon:          7.5812, Lat: 50.3561, Form: 3, Orientation: 270, Impact: 2,
+		Lights: [10]uint8{3, 4},
+	}
+	got, _ := DecodeInlandSignalStatus("8020sF@j:0AFQh>J33Qhq>000000")
+	if got != want {
+		fmt.Println("Got : ", got)
+		fmt.Println("Want: ", want)
+		t.Errorf("DecodeInlandSignalStatus(payload string)")
+	}
+	if _, err := DecodeInlandSignalStatus("8020sF@j611IHPW4dgu<00000000"); err == nil {
+		t.Errorf("DecodeInlandSignalStatus(payload string) accepted a Water Levels message")
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LineInfo holds what a LineParser found around a sentence.
+type LineInfo struct {
+	Timestamp time.Time // Zero if the line didn't carry a timestamp
+	Source    string    // Receiver or station ID, empty if the line didn't carry one
+}
+
+// A LineParser recognizes the decoration archived AIS dumps add to sentences and strips it,
+// keeping the timestamp and source it carries. Supported formats are:
+//
+//	1425772826.123 !AIVDM,...            UNIX timestamp prefix (seconds or milliseconds)
+//	2015-03-08T00:00:26Z rx1 !AIVDM,...  ISO 8601 timestamp prefix, optionally followed by a source
+//	\s:rx1,c:1425772826*hh\!AIVDM,...    NMEA 4.0 tag blocks
+//	!AIVDM,...*hh,rx1,1425772826         source and timestamp after the checksum (AISHub, USCG)
+//	$PGHP,1,2015,3,8,0,0,26,123,...      Gatehouse time and source of the next sentence
+//
+// Lines without decoration pass unchanged. A LineParser keeps state between lines (for $PGHP),
+// so use one per input.
+type LineParser struct {
+	pending LineInfo // From a $PGHP sentence, for the next sentence
+}
+
+// NewLineParser returns a LineParser.
+func NewLineParser() *LineParser {
+	return &LineParser{}
+}
+
+// Parse strips the decoration of a line and returns the sentence it carries with its timestamp
+// and source. Lines that only carry information for the next one ($PGHP) return an empty
+// sentence.
+func (p *LineParser) Parse(line string) (string, LineInfo, error) {
+	var info LineInfo
+	line = strings.TrimSpace(line)
+
+	// Everything before the sentence or tag block is a prefix
+	start := strings.IndexAny(line, "!$\\")
+	if start < 0 {
+		return line, info, nil
+	}
+	if start > 0 {
+		info = parsePrefix(line[:start])
+		line = line[start:]
+	}
+
+	if line[0] == '\\' {
+		end := strings.IndexByte(line[1:], '\\')
+		if end < 0 {
+			return line, info, errors.New("Tag block isn't terminated.")
+		}
+		tags, err := parseTagBlock(line[1 : end+1])
+		if err != nil {
+			return line, info, err
+		}
+		info = mergeLineInfo(info, tags)
+		line = line[end+2:]
+	}
+
+	if strings.HasPrefix(line, "$PGHP,") {
+		pending, err := parsePGHP(line)
+		if err != nil {
+			return line, info, err
+		}
+		p.pending = mergeLineInfo(info, pending)
+		return "", p.pending, nil
+	}
+
+	// Fields after the checksum
+	if star := strings.IndexByte(line, '*'); star >= 0 && len(line) > star+3 && line[star+3] == ',' {
+		info = mergeLineInfo(info, parseSuffix(line[star+4:]))
+		line = line[:star+3]
+	}
+
+	info = mergeLineInfo(p.pending, info)
+	p.pending = LineInfo{}
+	return line, info, nil
+}
+
+// mergeLineInfo fills the missing fields of a with those of b.
+func mergeLineInfo(a, b LineInfo) LineInfo {
+	if a.Timestamp.IsZero() {
+		a.Timestamp = b.Timestamp
+	}
+	if a.Source == "" {
+		a.Source = b.Source
+	}
+	return a
+}
+
+// isoLayouts are the ISO 8601 timestamp formats recognized in prefixes.
+var isoLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// parsePrefix reads a timestamp and a source from the text before a sentence.
+func parsePrefix(prefix string) LineInfo {
+	var info LineInfo
+	fields := strings.FieldsFunc(prefix, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == ';' || r == ',' || r == '|'
+	})
+	if len(fields) == 0 {
+		return info
+	}
+
+	if t, ok := parseEpoch(fields[0]); ok {
+		info.Timestamp = t
+		fields = fields[1:]
+	} else {
+		for _, layout := range isoLayouts {
+			if t, err := time.Parse(layout, fields[0]); err == nil {
+				info.Timestamp = t
+				fields = fields[1:]
+				break
+			}
+			// Date and time separated by space
+			if len(fields) > 1 {
+				if t, err := time.Parse(layout, fields[0]+" "+fields[1]); err == nil {
+					info.Timestamp = t
+					fields = fields[2:]
+					break
+				}
+			}
+		}
+	}
+
+	info.Source = strings.Join(fields, " ")
+	return info
+}
+
+// parseEpoch reads a UNIX timestamp in seconds (with optional fraction) or milliseconds.
+func parseEpoch(field string) (time.Time, bool) {
+	seconds, err := strconv.ParseFloat(field, 64)
+	if err != nil || seconds < 1e8 || strings.ContainsAny(field, "eE+-") {
+		return time.Time{}, false
+	}
+	if seconds > 1e11 { // Milliseconds
+		seconds /= 1000
+	}
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(math.Round(frac*1e3))*1e6).UTC(), true
+}
+
+// parseTagBlock reads the source (s:) and time (c:) of an NMEA 4.0 tag block.
+func parseTagBlock(block string) (LineInfo, error) {
+	var info LineInfo
+	star := strings.LastIndexByte(block, '*')
+	if star < 0 {
+		return info, errors.New("Tag block has no checksum.")
+	}
+	if csum, err := strconv.ParseUint(block[star+1:], 16, 8); err != nil || byte(csum) != Nmea183Checksum(block[:star]) {
+		return info, errors.New("Tag block checksum failed.")
+	}
+	for _, tag := range strings.Split(block[:star], ",") {
+		switch {
+		case strings.HasPrefix(tag, "c:"):
+			info.Timestamp, _ = parseEpoch(tag[2:])
+		case strings.HasPrefix(tag, "s:"):
+			info.Source = tag[2:]
+		}
+	}
+	return info, nil
+}
+
+// parseSuffix reads the fields some archives append after the checksum: a source and a UNIX
+// timestamp, in any order.
+func parseSuffix(suffix string) LineInfo {
+	var info LineInfo
+	var sources []string
+	for _, field := range strings.Split(suffix, ",") {
+		if t, ok := parseEpoch(field); ok && info.Timestamp.IsZero() {
+			info.Timestamp = t
+		} else if field != "" {
+			sources = append(sources, field)
+		}
+	}
+	info.Source = strings.Join(sources, ",")
+	return info
+}
+
+// parsePGHP reads a Gatehouse $PGHP,1 sentence: time of the next sentence and the country
+// (MID), region and physical shore station it was received by. The source is these three,
+// joined by "/".
+func parsePGHP(line string) (LineInfo, error) {
+	var info LineInfo
+	if !Nmea183ChecksumCheck(line) {
+		return info, errors.New("Checksum failed")
+	}
+	fields := strings.Split(line[:len(line)-3], ",")
+	if len(fields) < 12 || fields[1] != "1" {
+		return info, errors.New("Sentence PGHP isn't a type 1 (time and source) sentence.")
+	}
+
+	var t [7]int
+	for i := range t {
+		v, err := strconv.Atoi(fields[i+2])
+		if err != nil {
+			return info, errors.New("Sentence PGHP has invalid time.")
+		}
+		t[i] = v
+	}
+	info.Timestamp = time.Date(t[0], time.Month(t[1]), t[2], t[3], t[4], t[5], t[6]*1e6, time.UTC)
+	info.Source = strings.Join(fields[9:12], "/")
+	return info, nil
+}
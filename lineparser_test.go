@@ -0,0 +1,109 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLineParser(t *testing.T) {
+	const sentence = "!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F"
+	ts := time.Date(2015, 3, 8, 0, 0, 26, 0, time.UTC)
+	tsMilli := ts.Add(123 * time.Millisecond)
+
+	cases := []struct {
+		line     string
+		sentence string
+		info     LineInfo
+	}{
+		{sentence, sentence, LineInfo{}},
+		{sentence + "\r\n", sentence, LineInfo{}},
+		{"1425772826 " + sentence, sentence, LineInfo{Timestamp: ts}},
+		{"1425772826.123;" + sentence, sentence, LineInfo{Timestamp: tsMilli}},
+		{"1425772826123 rx1 " + sentence, sentence, LineInfo{Timestamp: tsMilli, Source: "rx1"}},
+		{"2015-03-08T00:00:26Z " + sentence, sentence, LineInfo{Timestamp: ts}},
+		{"2015-03-08T00:00:26.123Z\trx1\t" + sentence, sentence, LineInfo{Timestamp: tsMilli, Source: "rx1"}},
+		{"2015-03-08 00:00:26.123 " + sentence, sentence, LineInfo{Timestamp: tsMilli}},
+		{"2015-03-08T02:00:26+02:00," + sentence, sentence, LineInfo{Timestamp: ts.In(time.FixedZone("", 7200))}},
+		{"[rx1] " + sentence, sentence, LineInfo{Source: "[rx1]"}},
+		{"\\s:rORBCOMM000,c:1425772826*21\\" + sentence, sentence, LineInfo{Timestamp: ts, Source: "rORBCOMM000"}},
+		{"\\c:1425772826123*65\\" + sentence, sentence, LineInfo{Timestamp: tsMilli}},
+		{sentence + ",rB003669712,1425772826", sentence, LineInfo{Timestamp: ts, Source: "rB003669712"}},
+		{sentence + ",1425772826", sentence, LineInfo{Timestamp: ts}},
+		{"$PGHP,1,2015,3,8,0,0,26,123,219,,2190047,1,6F*69", "", LineInfo{Timestamp: tsMilli, Source: "219//2190047"}},
+		{sentence, sentence, LineInfo{Timestamp: tsMilli, Source: "219//2190047"}}, // After $PGHP
+		{sentence, sentence, LineInfo{}},                                           // $PGHP is used once
+	}
+	p := NewLineParser()
+	for _, c := range cases {
+		got, info, err := p.Parse(c.line)
+		if err != nil || got != c.sentence || !info.Timestamp.Equal(c.info.Timestamp) || info.Source != c.info.Source {
+			fmt.Println("Got : ", got, info, err)
+			fmt.Println("Want: ", c.sentence, c.info)
+			t.Errorf("LineParser.Parse(%q)", c.line)
+		}
+	}
+
+	invalid := []string{
+		"\\s:rORBCOMM000,c:1425772826*22\\" + sentence,
+		"\\s:rORBCOMM000,c:1425772826" + sentence,
+		"$PGHP,1,2015,3,8,0,0,26,123,219,,2190047,1,6F*68",
+		"$PGHP,2,2015,3,8,0,0,26*5A",
+	}
+	for _, line := range invalid {
+		if got, _, err := p.Parse(line); err == nil {
+			t.Errorf("LineParser.Parse(%q) = %q, want error", line, got)
+		}
+	}
+}
+
+func TestRouterArchive(t *testing.T) {
+	send := make(chan string)
+	receive := make(chan Message, 1024)
+	failed := make(chan FailedSentence, 1024)
+	go RouterWithOptions(send, receive, failed, RouterOptions{Archive: true})
+
+	for _, line := range []string{
+		"1425772826 rx1 !AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F",
+		"\\g:1-2-73874,s:rx2,c:1425772830*42\\!AIVDM,2,1,5,A,533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H,0*44",
+		"\\g:2-2-73874*62\\!AIVDM,2,2,5,A,51CU0E2CkP0,2*0C",
+	} {
+		send <- line
+	}
+	close(send)
+
+	want := []Message{
+		{Type: 3, Payload: "38u<a<?PAA2>P:WfuAO9PW<P0PuQ", Padding: 0,
+			Timestamp: time.Unix(1425772826, 0).UTC(), Source: "rx1"},
+		{Type: 5, Payload: "533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H51CU0E2CkP0", Padding: 2,
+			Timestamp: time.Unix(1425772830, 0).UTC(), Source: "rx2"},
+		{Type: 255},
+	}
+	for _, w := range want {
+		if got := <-receive; got != w {
+			fmt.Println("Got : ", got)
+			fmt.Println("Want: ", w)
+			t.Errorf("RouterWithOptions(..., RouterOptions{Archive: true})")
+		}
+	}
+	if len(failed) != 0 {
+		t.Errorf("RouterWithOptions(..., RouterOptions{Archive: true}) failed %v", <-failed)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MarineTrafficSignal is a DAC 1, FID 19 message. It reports the signal shown by a signal
+// station, e.g at a harbour entrance.
+type MarineTrafficSignal struct {
+	BinaryHeader
+	LinkageID  uint16 // Message linkage ID
+	Station    string // Name of signal station
+	Lon        float64
+	Lat        float64
+	Status     uint8     // enumerated, see TrafficSignalStatus
+	Signal     uint8     // Signal in service, enumerated, see TrafficSignals
+	Time       time.Time // UTC, hour and minute
+	NextSignal uint8     // Expected next signal, enumerated, see TrafficSignals
+}
+
+// DecodeMarineTrafficSignal decodes the payload of a Marine Traffic Signal message (type 8 DAC 1 FID 19).
+func DecodeMarineTrafficSignal(payload string) (MarineTrafficSignal, error) {
+	r := NewBitReader(payload)
+	var m MarineTrafficSignal
+
+	m.BinaryHeader, _ = decodeBinaryHeader(r)
+	if m.Type != 8 || m.DAC != 1 || m.FID != 19 {
+		return m, errors.New("Message isn't Marine Traffic Signal (type 8, DAC 1, FID 19).")
+	}
+
+	m.LinkageID = uint16(r.Uint(56, 65))
+
+	m.Station = r.String(66, 185)
+
+	m.Lon, m.Lat = cbnCoordinatesLow(186, r)
+
+	m.Status = uint8(r.Uint(235, 236))
+	m.Signal = uint8(r.Uint(237, 241))
+
+	timeString := fmt.Sprintf("%d:%d", r.Uint(242, 246), r.Uint(247, 252))
+	m.Time, _ = time.Parse("15:4", timeString)
+
+	m.NextSignal = uint8(r.Uint(253, 257))
+
+	return m, nil
+}
+
+var TrafficSignalStatus = [...]string{
+	"Not available", "In regular service", "Irregular service", "reserved",
+}
+
+var TrafficSignals = [...]string{
+	"Not available",
+	"IALA port traffic signal 1: Serious emergency, stop or divert according to instructions",
+	"IALA port traffic signal 2: Vessels shall not proceed",
+	"IALA port traffic signal 3: Vessels may proceed, one way traffic",
+	"IALA port traffic signal 4: Vessels may proceed, two way traffic",
+	"IALA port traffic signal 5: A vessel may proceed only when it has received specific orders to do so",
+	"IALA port traffic signal 2a: Vessels shall not proceed, except vessels navigating outside the main channel",
+	"IALA port traffic signal 5a: A vessel may proceed only when it has received specific orders to do so, " +
+		"except vessels navigating outside the main channel",
+	"Japan traffic signal I: In-bound only acceptable",
+	"Japan traffic signal O: Out-bound only acceptable",
+	"Japan traffic signal F: Both in- and out-bound acceptable",
+	"Japan traffic signal XI: Code will shift to I in due time",
+	"Japan traffic signal XO: Code will shift to O in due time",
+	"Japan traffic signal X: Vessels shall not proceed, except when directed by the competent authority",
+	"reserved", "reserved", "reserved", "reserved", "reserved", "reserved", "reserved", "reserved",
+	"reserved", "reserved", "reserved", "reserved", "reserved", "reserved", "reserved", "reserved",
+	"reserved", "reserved",
+}
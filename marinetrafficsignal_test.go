@@ -0,0 +1,42 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDecodeMarineTrafficSignal(t *testing.T) {
+	caseTime, _ := time.Parse("15:4", "14:20")
+	want := MarineTrafficSignal{
+		BinaryHeader: BinaryHeader{Type: 8, Repeat: 0, MMSI: 2442000, DAC: 1, FID: 19},
+		LinkageID:    3, Station: "IJMUIDEN NOORD", Lon: 4.5667, Lat: 52.4639,
+		Status: 1, Signal: 3, Time: caseTime, NextSignal: 2,
+	}
+	got, _ := DecodeMarineTrafficSignal("802E3400Dh39:=E945>P>??B40000000QLa60@M8o:200000000000000000")
+	if got != want {
+		fmt.Println("Got : ", got)
+		fmt.Println("Want: ", want)
+		t.Errorf("DecodeMarineTrafficSignal(payload string)")
+	}
+	if TrafficSignals[got.Signal] != "IALA port traffic signal 3: Vessels may proceed, one way traffic" {
+		t.Errorf("TrafficSignals[%d] = %s", got.Signal, TrafficSignals[got.Signal])
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultMergeWindow is the window of a Merger if Window isn't set.
+const DefaultMergeWindow = 2 * time.Second
+
+// A Merger suppresses the duplicates that appear when the messages of many receivers are
+// combined: a transmission heard by several receivers arrives once from each. Messages with the
+// same payload and padding within Window of the first copy are duplicates, whatever the
+// channel or talker of their sentences. The first copy goes through and the sources of all the
+// copies are recorded.
+//
+// Times are the Timestamp of the messages, or the time they are added if it isn't set, so
+// archives merge the same way as live feeds. Windows are closed by the time messages are added
+// though, so timestamps that come out of order (many archives, or archives mixed with live
+// feeds) don't close the windows of other messages early. It is safe for concurrent use.
+type Merger struct {
+	Window time.Duration
+
+	// Heard, if set, is called when the window of a message closes, with the first copy and the
+	// sources of all copies, in order of arrival. Copies without a source are counted as "".
+	Heard func(m Message, sources []string)
+
+	mu      sync.Mutex
+	entries map[mergerKey]*mergerEntry
+	order   []*mergerEntry // By time the first copy was added, for expiration
+	dups    int64
+}
+
+// mergerKey identifies the copies of a message.
+type mergerKey struct {
+	payload string
+	padding uint8
+}
+
+// mergerEntry is a message within its window.
+type mergerEntry struct {
+	key     mergerKey
+	first   Message
+	seen    time.Time // Time of the first copy
+	added   time.Time // When the first copy was added
+	closed  bool      // Replaced by a later message with the same key
+	sources []string
+}
+
+// heardMessage is an expired entry, waiting to be reported to Heard.
+type heardMessage struct {
+	m       Message
+	sources []string
+}
+
+// NewMerger returns a Merger with the given window.
+func NewMerger(window time.Duration) *Merger {
+	return &Merger{Window: window}
+}
+
+// Add records a message and reports if it is new, so it should be forwarded, or a duplicate.
+func (m *Merger) Add(msg Message) bool {
+	added := time.Now()
+	now := msg.Timestamp
+	if now.IsZero() {
+		now = added
+	}
+
+	m.mu.Lock()
+	if m.entries == nil {
+		m.entries = make(map[mergerKey]*mergerEntry)
+	}
+	expired := m.expire(added, false)
+
+	key := mergerKey{msg.Payload, msg.Padding}
+	e, dup := m.entries[key]
+	if dup && (now.Sub(e.seen) >= m.window() || e.seen.Sub(now) >= m.window()) {
+		// The same message again, outside the window of the first copy
+		e.closed = true
+		delete(m.entries, key)
+		if m.Heard != nil {
+			expired = append(expired, heardMessage{e.first, e.sources})
+		}
+		dup = false
+	}
+	if dup {
+		m.dups++
+		found := false
+		for _, s := range e.sources {
+			found = found || s == msg.Source
+		}
+		if !found {
+			e.sources = append(e.sources, msg.Source)
+		}
+	} else {
+		e = &mergerEntry{key: key, first: msg, seen: now, added: added, sources: []string{msg.Source}}
+		m.entries[key] = e
+		m.order = append(m.order, e)
+	}
+	m.mu.Unlock()
+
+	m.report(expired)
+	return !dup
+}
+
+// Flush closes the windows of all messages, reporting them to Heard.
+func (m *Merger) Flush() {
+	m.mu.Lock()
+	expired := m.expire(time.Time{}, true)
+	m.mu.Unlock()
+	m.report(expired)
+}
+
+// Duplicates returns the number of duplicates suppressed.
+func (m *Merger) Duplicates() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.dups
+}
+
+// window returns the window of the merger.
+func (m *Merger) window() time.Duration {
+	if m.Window <= 0 {
+		return DefaultMergeWindow
+	}
+	return m.Window
+}
+
+// expire removes the entries added a window before now, or all of them. It is called with mu
+// held.
+func (m *Merger) expire(now time.Time, all bool) []heardMessage {
+	window := m.window()
+	var expired []heardMessage
+	n := 0
+	for ; n < len(m.order); n++ {
+		e := m.order[n]
+		if e.closed {
+			continue
+		}
+		if !all && now.Sub(e.added) < window {
+			break
+		}
+		delete(m.entries, e.key)
+		if m.Heard != nil {
+			expired = append(expired, heardMessage{e.first, e.sources})
+		}
+	}
+	m.order = m.order[n:]
+	return expired
+}
+
+func (m *Merger) report(expired []heardMessage) {
+	for _, h := range expired {
+		m.Heard(h.m, h.sources)
+	}
+}
+
+// Run forwards the new messages from in to out, until in is closed. A message of type 255 (the
+// end of a Router) flushes the merger and is forwarded, so Run can sit between a Router and its
+// consumer.
+func (m *Merger) Run(in <-chan Message, out chan<- Message) {
+	for msg := range in {
+		if msg.Type == 255 {
+			m.Flush()
+			out <- msg
+			continue
+		}
+		if m.Add(msg) {
+			out <- msg
+		}
+	}
+	m.Flush()
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMerger(t *testing.T) {
+	t0 := time.Unix(1425772826, 0).UTC()
+	a := "38u<a<?PAA2>P:WfuAO9PW<P0PuQ"
+	b := "B3ujWF0000DdVU8O:1H03wi5oP06"
+	cases := []struct {
+		m    Message
+		want bool
+	}{
+		{Message{Type: 3, Payload: a, Timestamp: t0, Source: "rx1"}, true},
+		{Message{Type: 3, Payload: a, Timestamp: t0.Add(500 * time.Millisecond), Source: "rx2"}, false},
+		{Message{Type: 3, Payload: a, Padding: 2, Timestamp: t0.Add(500 * time.Millisecond), Source: "rx2"}, true},
+		{Message{Type: 18, Payload: b, Timestamp: t0.Add(time.Second), Source: "rx1"}, true},
+		{Message{Type: 3, Payload: a, Timestamp: t0.Add(1500 * time.Millisecond), Source: "rx1"}, false},
+		{Message{Type: 3, Payload: a, Timestamp: t0.Add(3 * time.Second), Source: "rx3"}, true}, // Window closed
+	}
+
+	type heard struct {
+		m       Message
+		sources []string
+	}
+	var got []heard
+	m := NewMerger(2 * time.Second)
+	m.Heard = func(msg Message, sources []string) {
+		got = append(got, heard{msg, sources})
+	}
+	for _, c := range cases {
+		if fresh := m.Add(c.m); fresh != c.want {
+			t.Errorf("Merger.Add(%v) = %t, want %t", c.m, fresh, c.want)
+		}
+	}
+	m.Flush()
+
+	want := []heard{
+		{cases[0].m, []string{"rx1", "rx2"}},
+		{cases[2].m, []string{"rx2"}},
+		{cases[3].m, []string{"rx1"}},
+		{cases[5].m, []string{"rx3"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		fmt.Println("Got : ", got)
+		fmt.Println("Want: ", want)
+		t.Errorf("Merger.Heard")
+	}
+	if m.Duplicates() != 2 {
+		t.Errorf("Merger.Duplicates() = %d, want 2", m.Duplicates())
+	}
+}
+
+func TestMergerMixed(t *testing.T) {
+	t0 := time.Unix(1425772826, 0).UTC()
+	a := "38u<a<?PAA2>P:WfuAO9PW<P0PuQ"
+	b := "B3ujWF0000DdVU8O:1H03wi5oP06"
+	var heard []Message
+	m := NewMerger(50 * time.Millisecond)
+	m.Heard = func(msg Message, sources []string) { heard = append(heard, msg) }
+
+	// A live message between the copies of an archived one doesn't close their window
+	cases := []struct {
+		m    Message
+		want bool
+	}{
+		{Message{Type: 3, Payload: a, Timestamp: t0, Source: "rx1"}, true},
+		{Message{Type: 18, Payload: b, Source: "live"}, true},
+		{Message{Type: 3, Payload: a, Timestamp: t0.Add(20 * time.Millisecond), Source: "rx2"}, false},
+		{Message{Type: 18, Payload: b, Timestamp: t0, Source: "rx1"}, true}, // Far from the live copy
+	}
+	for _, c := range cases {
+		if fresh := m.Add(c.m); fresh != c.want {
+			t.Errorf("Merger.Add(%v) = %t, want %t", c.m, fresh, c.want)
+		}
+	}
+	if len(heard) != 1 || heard[0] != cases[1].m {
+		t.Errorf("Merger.Heard got %v, want %v", heard, cases[1].m)
+	}
+
+	// Windows close as time passes
+	time.Sleep(60 * time.Millisecond)
+	m.Add(Message{Type: 3, Payload: b, Source: "live"})
+	if len(heard) != 3 || heard[1] != cases[0].m || heard[2] != cases[3].m {
+		t.Errorf("Merger.Heard got %v", heard)
+	}
+	if m.Duplicates() != 1 {
+		t.Errorf("Merger.Duplicates() = %d, want 1", m.Duplicates())
+	}
+}
+
+func TestMergerRun(t *testing.T) {
+	in := make(chan Message, 8)
+	out := make(chan Message, 8)
+	m := NewMerger(0)
+	heard := 0
+	m.Heard = func(msg Message, sources []string) { heard++ }
+
+	in <- Message{Type: 3, Payload: "38u<a<?PAA2>P:WfuAO9PW<P0PuQ", Source: "rx1"}
+	in <- Message{Type: 3, Payload: "38u<a<?PAA2>P:WfuAO9PW<P0PuQ", Source: "rx2"}
+	in <- Message{Type: 255}
+	close(in)
+	m.Run(in, out)
+	close(out)
+
+	var got []Message
+	for msg := range out {
+		got = append(got, msg)
+	}
+	want := []Message{{Type: 3, Payload: "38u<a<?PAA2>P:WfuAO9PW<P0PuQ", Source: "rx1"}, {Type: 255}}
+	if !reflect.DeepEqual(got, want) || heard != 1 {
+		fmt.Println("Got : ", got, heard)
+		fmt.Println("Want: ", want)
+		t.Errorf("Merger.Run(in <-chan Message, out chan<- Message)")
+	}
+}
@@ -17,13 +17,27 @@
 
 package aislib
 
-import "encoding/hex"
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// ChecksumMode sets how strictly the checksum of NMEA183 sentences is checked.
+type ChecksumMode uint8
+
+// Checksum modes, from the most to the least strict.
+const (
+	ChecksumStrict   ChecksumMode = iota // The sentence must end with a valid checksum
+	ChecksumTrim                         // Like strict, but trailing whitespace (\r, \n, spaces) is removed
+	ChecksumOptional                     // Like trim, but sentences without checksum are accepted
+	ChecksumIgnore                       // The checksum isn't checked at all
+)
 
 // Nmea183ChecksumCheck performs a checksum check for NMEA183 sentences.
 // AIS messages are NMEA183 encoded.
 func Nmea183ChecksumCheck(sentence string) bool {
 	length := len(sentence)
-	if length < 5 { // Sentence isn't long enough to have a csum, avoid bounds out of range
+	if length < 5 || sentence[length-3] != '*' { // Sentence isn't long enough to have a csum, avoid bounds out of range
 		return false
 	}
 
@@ -35,15 +49,43 @@ func Nmea183ChecksumCheck(sentence string) bool {
 
 	// The checksum is calculated from the whole sentence except
 	// the first and last three characters
-	bline := []byte(sentence[1 : length-3])
-	ccsum := bline[0]
+	return csum[0] == Nmea183Checksum(sentence[:length-3])
+}
+
+// Nmea183Checksum calculates the checksum of an NMEA183 sentence. The start delimiter (! or $)
+// and anything from the checksum delimiter (*) on are skipped, so it works for sentences with
+// or without a checksum. Format it with %02X to append it to a sentence.
+func Nmea183Checksum(sentence string) byte {
+	if len(sentence) > 0 && (sentence[0] == '!' || sentence[0] == '$') {
+		sentence = sentence[1:]
+	}
+	if i := strings.IndexByte(sentence, '*'); i >= 0 {
+		sentence = sentence[:i]
+	}
+
 	// The checksum is calculated by XOR'ing all the characters
-	for i := 1; i < len(bline); i++ {
-		ccsum ^= bline[i]
+	var csum byte
+	for i := 0; i < len(sentence); i++ {
+		csum ^= sentence[i]
+	}
+	return csum
+}
+
+// Nmea183ChecksumCheckMode checks the checksum of an NMEA183 sentence according to mode. It
+// returns the sentence without trailing whitespace (if the mode removes it) and the result.
+func Nmea183ChecksumCheckMode(sentence string, mode ChecksumMode) (string, bool) {
+	if mode == ChecksumStrict {
+		return sentence, Nmea183ChecksumCheck(sentence)
 	}
 
-	if csum[0] == ccsum {
-		return true
+	sentence = strings.TrimRight(sentence, " \t\r\n")
+	switch mode {
+	case ChecksumOptional:
+		if len(sentence) < 3 || sentence[len(sentence)-3] != '*' {
+			return sentence, strings.IndexByte(sentence, '*') < 0
+		}
+	case ChecksumIgnore:
+		return sentence, true
 	}
-	return false
+	return sentence, Nmea183ChecksumCheck(sentence)
 }
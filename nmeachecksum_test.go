@@ -17,7 +17,59 @@
 
 package aislib
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
+
+func TestNmea183Checksum(t *testing.T) {
+	cases := []struct {
+		sentence string
+		want     byte
+	}{
+		{"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F", 0x6F},
+		{"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0", 0x6F},
+		{"AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0", 0x6F},
+		{"$GPHDT,274.07,T*03", 0x03},
+		{"", 0},
+	}
+	for _, c := range cases {
+		if got := Nmea183Checksum(c.sentence); got != c.want {
+			t.Errorf("Nmea183Checksum(%q) = %02X, want %02X", c.sentence, got, c.want)
+		}
+	}
+}
+
+func TestNmea183ChecksumCheckMode(t *testing.T) {
+	const valid = "!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F"
+	cases := []struct {
+		sentence string
+		mode     ChecksumMode
+		want     bool
+	}{
+		{valid, ChecksumStrict, true},
+		{valid + "\r\n", ChecksumStrict, false},
+		{"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6f", ChecksumStrict, true},
+		{"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6E", ChecksumStrict, false},
+		{"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,06F", ChecksumStrict, false},
+		{valid + " \r\n", ChecksumTrim, true},
+		{"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0", ChecksumTrim, false},
+		{"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0\r", ChecksumOptional, true},
+		{valid + "\r", ChecksumOptional, true},
+		{"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6E", ChecksumOptional, false},
+		{"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6", ChecksumOptional, false},
+		{"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6E", ChecksumIgnore, true},
+	}
+	for _, c := range cases {
+		got, ok := Nmea183ChecksumCheckMode(c.sentence, c.mode)
+		if ok != c.want {
+			t.Errorf("Nmea183ChecksumCheckMode(%q, %d) = %t, want %t", c.sentence, c.mode, ok, c.want)
+		}
+		if c.mode != ChecksumStrict && got != strings.TrimRight(c.sentence, " \r\n") {
+			t.Errorf("Nmea183ChecksumCheckMode(%q, %d) returned %q", c.sentence, c.mode, got)
+		}
+	}
+}
 
 func BenchmarkNmea183ChecksumCheck(b *testing.B) {
 	for i := 0; i < b.N; i++ {
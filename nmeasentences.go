@@ -0,0 +1,287 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An NMEASentence is a parsed NMEA183 sentence other than AIVDM/AIVDO, as found in feeds that
+// multiplex AIS with GNSS receivers, compasses and other instruments.
+type NMEASentence interface {
+	// Formatter returns the sentence formatter, e.g "GGA"
+	Formatter() string
+}
+
+// NMEAHeader holds the fields every parsed NMEA sentence carries.
+type NMEAHeader struct {
+	Talker string // Talker ID, e.g "GP" for GPS, "GN" for multiple GNSS, "HE" for gyro compass
+}
+
+// GGA is a Global Positioning System Fix Data sentence. Values that are missing are NaN.
+type GGA struct {
+	NMEAHeader
+	Time            time.Time // Time of fix (UTC), year isn't transmitted and is left at 0. Zero if missing
+	Lon             float64
+	Lat             float64
+	Quality         uint8 // Fix quality, see GGAFixQuality
+	Satellites      uint8 // Number of satellites in use
+	HDOP            float64
+	Altitude        float64 // Antenna altitude above mean sea level (meters)
+	GeoidSeparation float64 // Meters
+}
+
+// RMC is a Recommended Minimum Specific GNSS Data sentence. Values that are missing are NaN.
+type RMC struct {
+	NMEAHeader
+	Time      time.Time // Date and time of fix (UTC), zero if either is missing
+	Valid     bool      // Status A (valid) or V (warning)
+	Lon       float64
+	Lat       float64
+	Speed     float64 // Speed over ground (knots)
+	Course    float64 // Course over ground (degrees true)
+	Variation float64 // Magnetic variation (degrees, negative is west)
+}
+
+// VTG is a Course Over Ground and Ground Speed sentence. Values that are missing are NaN.
+type VTG struct {
+	NMEAHeader
+	CourseTrue     float64 // Degrees
+	CourseMagnetic float64 // Degrees
+	SpeedKnots     float64
+	SpeedKmh       float64
+}
+
+// HDT is a Heading, True sentence. A missing heading is NaN.
+type HDT struct {
+	NMEAHeader
+	Heading float64 // Degrees true
+}
+
+// ZDA is a Time and Date sentence.
+type ZDA struct {
+	NMEAHeader
+	Time        time.Time // UTC
+	ZoneHours   int       // Local zone hours (-13 to 13)
+	ZoneMinutes int       // Local zone minutes
+}
+
+// Formatter returns "GGA".
+func (GGA) Formatter() string { return "GGA" }
+
+// Formatter returns "RMC".
+func (RMC) Formatter() string { return "RMC" }
+
+// Formatter returns "VTG".
+func (VTG) Formatter() string { return "VTG" }
+
+// Formatter returns "HDT".
+func (HDT) Formatter() string { return "HDT" }
+
+// Formatter returns "ZDA".
+func (ZDA) Formatter() string { return "ZDA" }
+
+// GGA fix quality codes.
+var GGAFixQuality = [...]string{
+	"Fix not available", "GPS fix", "Differential GPS fix", "PPS fix", "Real Time Kinematic",
+	"Float RTK", "Estimated (dead reckoning)", "Manual input mode", "Simulation mode",
+}
+
+// nmeaParsers holds the parsers of the supported sentences, by formatter. They take the fields
+// of the sentence, starting with the address field.
+var nmeaParsers = map[string]func([]string) (NMEASentence, error){
+	"GGA": parseGGA,
+	"RMC": parseRMC,
+	"VTG": parseVTG,
+	"HDT": parseHDT,
+	"ZDA": parseZDA,
+	"ALR": parseALR,
+	"TXT": parseTXT,
+	"VSD": parseVSD,
+	"SSD": parseSSD,
+	"ABK": parseABK,
+	"ABM": parseABM,
+	"BBM": parseBBM,
+}
+
+// ParseNMEASentence parses an NMEA183 sentence of a supported type. The checksum isn't checked,
+// this is the job of Nmea183ChecksumCheck or the Router.
+func ParseNMEASentence(sentence string) (NMEASentence, error) {
+	if i := strings.IndexByte(sentence, '*'); i >= 0 {
+		sentence = sentence[:i]
+	}
+	fields := strings.Split(sentence, ",")
+	if len(fields[0]) != 6 || (fields[0][0] != '$' && fields[0][0] != '!') {
+		return nil, errors.New("Sentence isn't NMEA183.")
+	}
+
+	parse, ok := nmeaParsers[fields[0][3:]]
+	if !ok {
+		return nil, errors.New("Sentence " + fields[0][3:] + " isn't supported.")
+	}
+	return parse(fields)
+}
+
+// nmeaFields checks that a sentence has at least n fields (address field included).
+func nmeaFields(fields []string, n int) error {
+	if len(fields) < n {
+		return errors.New("Sentence " + fields[0][3:] + " is too short.")
+	}
+	return nil
+}
+
+// nmeaFloat parses a number, missing or invalid numbers are NaN.
+func nmeaFloat(field string) float64 {
+	f, err := strconv.ParseFloat(field, 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return f
+}
+
+// nmeaCoordinate parses a latitude (ddmm.mm) or longitude (dddmm.mm) and its hemisphere to
+// decimal degrees.
+func nmeaCoordinate(field, hemisphere string) float64 {
+	value := nmeaFloat(field)
+	degrees := math.Floor(value / 100)
+	value = degrees + (value-degrees*100)/60
+	if hemisphere == "S" || hemisphere == "W" {
+		value = -value
+	}
+	return value
+}
+
+// nmeaTime parses a time of day (hhmmss.ss) and adds it to date.
+func nmeaTime(field string, date time.Time) (time.Time, error) {
+	if len(field) < 6 {
+		return time.Time{}, errors.New("Time '" + field + "' isn't valid.")
+	}
+	hour, err1 := strconv.Atoi(field[:2])
+	minute, err2 := strconv.Atoi(field[2:4])
+	second, err3 := strconv.ParseFloat(field[4:], 64)
+	if err1 != nil || err2 != nil || err3 != nil || hour < 0 || hour > 23 || minute < 0 || minute > 59 ||
+		!(second >= 0 && second < 61) { // 60 for leap seconds
+		return time.Time{}, errors.New("Time '" + field + "' isn't valid.")
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, time.UTC).
+		Add(time.Duration(second * float64(time.Second))).Round(time.Millisecond), nil
+}
+
+func parseGGA(fields []string) (NMEASentence, error) {
+	var s GGA
+	if err := nmeaFields(fields, 12); err != nil {
+		return s, err
+	}
+	s.Talker = fields[0][1:3]
+
+	var err error
+	if fields[1] != "" { // Receivers without a fix may leave it empty
+		if s.Time, err = nmeaTime(fields[1], time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+			return s, err
+		}
+	}
+	s.Lat = nmeaCoordinate(fields[2], fields[3])
+	s.Lon = nmeaCoordinate(fields[4], fields[5])
+
+	quality, _ := strconv.Atoi(fields[6])
+	s.Quality = uint8(quality)
+	satellites, _ := strconv.Atoi(fields[7])
+	s.Satellites = uint8(satellites)
+
+	s.HDOP = nmeaFloat(fields[8])
+	s.Altitude = nmeaFloat(fields[9])
+	s.GeoidSeparation = nmeaFloat(fields[11])
+	return s, nil
+}
+
+func parseRMC(fields []string) (NMEASentence, error) {
+	var s RMC
+	if err := nmeaFields(fields, 12); err != nil {
+		return s, err
+	}
+	s.Talker = fields[0][1:3]
+
+	if fields[1] != "" && fields[9] != "" { // Receivers without a fix may leave them empty
+		date, err := time.Parse("020106", fields[9])
+		if err != nil {
+			return s, errors.New("Date '" + fields[9] + "' isn't valid.")
+		}
+		if s.Time, err = nmeaTime(fields[1], date); err != nil {
+			return s, err
+		}
+	}
+	s.Valid = fields[2] == "A"
+	s.Lat = nmeaCoordinate(fields[3], fields[4])
+	s.Lon = nmeaCoordinate(fields[5], fields[6])
+	s.Speed = nmeaFloat(fields[7])
+	s.Course = nmeaFloat(fields[8])
+
+	s.Variation = nmeaFloat(fields[10])
+	if fields[11] == "W" {
+		s.Variation = -s.Variation
+	}
+	return s, nil
+}
+
+func parseVTG(fields []string) (NMEASentence, error) {
+	var s VTG
+	if err := nmeaFields(fields, 9); err != nil {
+		return s, err
+	}
+	s.Talker = fields[0][1:3]
+
+	s.CourseTrue = nmeaFloat(fields[1])
+	s.CourseMagnetic = nmeaFloat(fields[3])
+	s.SpeedKnots = nmeaFloat(fields[5])
+	s.SpeedKmh = nmeaFloat(fields[7])
+	return s, nil
+}
+
+func parseHDT(fields []string) (NMEASentence, error) {
+	var s HDT
+	if err := nmeaFields(fields, 2); err != nil {
+		return s, err
+	}
+	s.Talker = fields[0][1:3]
+
+	s.Heading = nmeaFloat(fields[1])
+	return s, nil
+}
+
+func parseZDA(fields []string) (NMEASentence, error) {
+	var s ZDA
+	if err := nmeaFields(fields, 7); err != nil {
+		return s, err
+	}
+	s.Talker = fields[0][1:3]
+
+	date, err := time.Parse("02 01 2006", fields[2]+" "+fields[3]+" "+fields[4])
+	if err != nil {
+		return s, errors.New("Date '" + strings.Join(fields[2:5], ",") + "' isn't valid.")
+	}
+	if s.Time, err = nmeaTime(fields[1], date); err != nil {
+		return s, err
+	}
+	s.ZoneHours, _ = strconv.Atoi(fields[5])
+	s.ZoneMinutes, _ = strconv.Atoi(fields[6])
+	return s, nil
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// nmeaEqual compares parsed sentences, allowing for rounding errors and NaN in float fields.
+func nmeaEqual(a, b interface{}) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Type() != vb.Type() {
+		return false
+	}
+	for i := 0; i < va.NumField(); i++ {
+		fa, fb := va.Field(i), vb.Field(i)
+		if fa.Kind() == reflect.Float64 {
+			x, y := fa.Float(), fb.Float()
+			if !(math.IsNaN(x) && math.IsNaN(y)) && math.Abs(x-y) > 1e-9 {
+				return false
+			}
+		} else if !reflect.DeepEqual(fa.Interface(), fb.Interface()) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestParseNMEASentence(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		sentence string
+		want     NMEASentence
+	}{
+		{
+			"$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47",
+			GGA{NMEAHeader: NMEAHeader{"GP"}, Time: time.Date(0, 1, 1, 12, 35, 19, 0, time.UTC),
+				Lon: 11.516666666666667, Lat: 48.1173, Quality: 1, Satellites: 8, HDOP: 0.9,
+				Altitude: 545.4, GeoidSeparation: 46.9},
+		},
+		{
+			"$GNGGA,001043.00,,,,,0,00,99.99,,,,,,*7E",
+			GGA{NMEAHeader: NMEAHeader{"GN"}, Time: time.Date(0, 1, 1, 0, 10, 43, 0, time.UTC),
+				Lon: nan, Lat: nan, HDOP: 99.99, Altitude: nan, GeoidSeparation: nan},
+		},
+		{
+			"$GPRMC,123519,A,4807.038,S,01131.000,W,022.4,084.4,230394,003.1,W*65",
+			RMC{NMEAHeader: NMEAHeader{"GP"}, Time: time.Date(1994, 3, 23, 12, 35, 19, 0, time.UTC),
+				Valid: true, Lon: -11.516666666666667, Lat: -48.1173, Speed: 22.4, Course: 84.4,
+				Variation: -3.1},
+		},
+		{
+			"$GPGGA,,,,,,0,,,,,,,,*66",
+			GGA{NMEAHeader: NMEAHeader{"GP"}, Lon: nan, Lat: nan, HDOP: nan, Altitude: nan,
+				GeoidSeparation: nan},
+		},
+		{
+			"$GPRMC,,V,,,,,,,,,,N*53",
+			RMC{NMEAHeader: NMEAHeader{"GP"}, Lon: nan, Lat: nan, Speed: nan, Course: nan,
+				Variation: nan},
+		},
+		{
+			"$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K*48",
+			VTG{NMEAHeader: NMEAHeader{"GP"}, CourseTrue: 54.7, CourseMagnetic: 34.4, SpeedKnots: 5.5,
+				SpeedKmh: 10.2},
+		},
+		{
+			"$HEHDT,274.07,T*19",
+			HDT{NMEAHeader: NMEAHeader{"HE"}, Heading: 274.07},
+		},
+		{
+			"$GPZDA,201530.25,04,07,2002,-05,30*4C",
+			ZDA{NMEAHeader: NMEAHeader{"GP"}, Time: time.Date(2002, 7, 4, 20, 15, 30, 250000000, time.UTC),
+				ZoneHours: -5, ZoneMinutes: 30},
+		},
+	}
+	for _, c := range cases {
+		got, err := ParseNMEASentence(c.sentence)
+		if err != nil || !nmeaEqual(got, c.want) {
+			fmt.Printf("Got : %+v %v\n", got, err)
+			fmt.Printf("Want: %+v\n", c.want)
+			t.Errorf("ParseNMEASentence(sentence string)")
+		}
+	}
+
+	invalid := []string{
+		"$GPGSV,3,1,11,03,03,111,00,04,15,270,00,06,01,010,00,13,06,292,00*74",
+		"$GPGGA,123519,4807.038,N*00",
+		"$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,320394,003.1,W*00",
+		"$GPRMC,1235,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*00",
+		"$GPGGA,1235,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*00",
+		"$GPGGA,996000,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*00",
+		"$GPGGA,126000,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*00",
+		"$GPRMC,123561,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*00",
+		"$GPZDA,241530.25,04,07,2002,-05,30*00",
+		"$GPZDA,2015,04,07,2002,00,00*00",
+		"GPHDT,274.07,T",
+		"",
+	}
+	for _, s := range invalid {
+		if got, err := ParseNMEASentence(s); err == nil {
+			t.Errorf("ParseNMEASentence(%q) = %+v, want error", s, got)
+		}
+	}
+}
+
+func TestRouterNMEA(t *testing.T) {
+	send := make(chan string)
+	receive := make(chan Message, 1024)
+	nmea := make(chan NMEASentence, 1024)
+	failed := make(chan FailedSentence, 1024)
+
+	go RouterWithOptions(send, receive, failed, RouterOptions{NMEA: nmea})
+	for _, s := range []string{
+		"$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47",
+		"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F",
+		"$HEHDT,274.07,T*19",
+		"$HEHDT,274.07,T*18",
+		"$GPGSV,3,1,11,03,03,111,00,04,15,270,00,06,01,010,00,13,06,292,00*74",
+	} {
+		send <- s
+	}
+	close(send)
+
+	if got := <-receive; got.Type != 3 {
+		t.Errorf("RouterWithOptions(...) didn't route the AIS sentence, got %v", got)
+	}
+	if got := <-nmea; got.Formatter() != "GGA" {
+		t.Errorf("RouterWithOptions(...) NMEA sentence %s, want GGA", got.Formatter())
+	}
+	if got := <-nmea; got.Formatter() != "HDT" || got.(HDT).Heading != 274.07 {
+		t.Errorf("RouterWithOptions(...) NMEA sentence %+v, want HDT", got)
+	}
+	<-receive // Wait for the router to finish
+	if len(nmea) != 0 || len(failed) != 2 {
+		t.Errorf("RouterWithOptions(...) %d extra NMEA sentences and %d failed, want 0 and 2", len(nmea), len(failed))
+	}
+}
@@ -0,0 +1,548 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// maxPcapBlock is the largest record or block a PcapReader accepts.
+const maxPcapBlock = 16 << 20
+
+// Link layer types of the captures a PcapReader understands.
+const (
+	linkNull     = 0
+	linkEthernet = 1
+	linkRaw      = 101
+	linkLoop     = 108
+	linkSLL      = 113
+	linkIPv4     = 228
+	linkIPv6     = 229
+	linkSLL2     = 276
+)
+
+// A PcapReader reads NMEA sentences out of a packet capture (e.g of tcpdump), in the classic
+// pcap or the pcapng format. It extracts the payloads of UDP datagrams and reassembles TCP
+// streams, then returns their lines or, through Next, the messages they carry, like Decoder.
+//
+// Lines carry the capture time of their packet as Timestamp and the address of the sender
+// (ip:port) as Source, unless they come with their own (tag blocks when Archive is set).
+// TCP reassembly is simple: segments must arrive in order or within a few segments of it, and
+// a line cut by a lost segment is dropped. IP fragments are ignored.
+type PcapReader struct {
+	// Failed, if set, is called for every line that can't be used.
+	Failed func(FailedSentence)
+	// MaxLineLength, if set, overrides DefaultMaxLineLength.
+	MaxLineLength int
+
+	r     *bufio.Reader
+	order binary.ByteOrder
+	ng    bool
+	nano  bool            // Classic pcap with nanosecond timestamps
+	link  uint32          // Link type of classic pcap
+	ifs   []pcapInterface // Interfaces of the current pcapng section
+
+	streams map[string]*pcapStream
+	lines   []pcapLine
+	ended   bool
+
+	opts    RouterOptions
+	routers map[string]*router
+	queue   []Message
+}
+
+// pcapInterface is an interface described in a pcapng section.
+type pcapInterface struct {
+	link      uint16
+	perSecond uint64 // Timestamp units per second
+}
+
+// pcapStream is the state of a TCP stream.
+type pcapStream struct {
+	next    uint32            // Next expected sequence number
+	partial []byte            // Start of a line that isn't complete yet
+	early   map[uint32][]byte // Segments that arrived before the ones preceding them
+	skip    bool              // The partial line lost a segment, drop it
+	info    LineInfo          // Of the last segment
+}
+
+// pcapLine is a line read from a capture.
+type pcapLine struct {
+	text string
+	info LineInfo
+}
+
+// NewPcapReader reads the header of a capture and returns a PcapReader for it. Messages are
+// assembled with opts.
+func NewPcapReader(r io.Reader, opts RouterOptions) (*PcapReader, error) {
+	p := &PcapReader{r: bufio.NewReader(r), opts: opts,
+		streams: make(map[string]*pcapStream), routers: make(map[string]*router)}
+	magic, err := p.r.Peek(4)
+	if err != nil {
+		return nil, errors.New("Not a pcap or pcapng file.")
+	}
+	switch binary.LittleEndian.Uint32(magic) {
+	case 0x0A0D0D0A:
+		p.ng = true
+		return p, nil
+	case 0xA1B2C3D4:
+		p.order = binary.LittleEndian
+	case 0xA1B23C4D:
+		p.order, p.nano = binary.LittleEndian, true
+	case 0xD4C3B2A1:
+		p.order = binary.BigEndian
+	case 0x4D3CB2A1:
+		p.order, p.nano = binary.BigEndian, true
+	default:
+		return nil, errors.New("Not a pcap or pcapng file.")
+	}
+	header := make([]byte, 24)
+	if _, err := io.ReadFull(p.r, header); err != nil {
+		return nil, err
+	}
+	p.link = p.order.Uint32(header[20:]) & 0xFFFF
+	return p, nil
+}
+
+// Next returns the next message of the capture. At the end it returns io.EOF, or the error that
+// stopped reading.
+func (p *PcapReader) Next() (Message, error) {
+	for len(p.queue) == 0 {
+		line, info, err := p.ReadLine()
+		if err != nil {
+			return Message{}, err
+		}
+		r, ok := p.routers[info.Source]
+		if !ok {
+			r = newRouter(p.opts, func(m Message) {
+				p.queue = append(p.queue, m)
+			}, p.failed)
+			p.routers[info.Source] = r
+		}
+		r.defaults = info
+		r.process(line)
+	}
+	m := p.queue[0]
+	p.queue = p.queue[1:]
+	return m, nil
+}
+
+// ReadLine returns the next line of the capture, with the capture time of its packet and its
+// sender. At the end it returns io.EOF, or the error that stopped reading.
+func (p *PcapReader) ReadLine() (string, LineInfo, error) {
+	for len(p.lines) == 0 {
+		if p.ended {
+			return "", LineInfo{}, io.EOF
+		}
+		if err := p.readPacket(); err != nil {
+			if err != io.EOF {
+				return "", LineInfo{}, err
+			}
+			p.ended = true
+			p.flushStreams()
+		}
+	}
+	l := p.lines[0]
+	p.lines = p.lines[1:]
+	return l.text, l.info, nil
+}
+
+func (p *PcapReader) failed(f FailedSentence) {
+	if p.Failed != nil {
+		p.Failed(f)
+	}
+}
+
+// readPacket reads the next packet (or pcapng block) and queues its lines.
+func (p *PcapReader) readPacket() error {
+	if p.ng {
+		return p.readBlock()
+	}
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(p.r, header); err != nil {
+		return err
+	}
+	size := p.order.Uint32(header[8:])
+	if size > maxPcapBlock {
+		return errors.New("Capture record too large.")
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(p.r, data); err != nil {
+		return unexpected(err)
+	}
+	sec, frac := int64(p.order.Uint32(header)), int64(p.order.Uint32(header[4:]))
+	if !p.nano {
+		frac *= 1000
+	}
+	p.packet(p.link, time.Unix(sec, frac).UTC(), data)
+	return nil
+}
+
+// readBlock reads a pcapng block.
+func (p *PcapReader) readBlock() error {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(p.r, header); err != nil {
+		return err
+	}
+	kind := binary.LittleEndian.Uint32(header)
+	if kind == 0x0A0D0D0A { // Section header, learn the byte order
+		magic, err := p.r.Peek(4)
+		if err != nil {
+			return unexpected(err)
+		}
+		switch binary.LittleEndian.Uint32(magic) {
+		case 0x1A2B3C4D:
+			p.order = binary.LittleEndian
+		case 0x4D3C2B1A:
+			p.order = binary.BigEndian
+		default:
+			return errors.New("Bad pcapng section header.")
+		}
+		p.ifs = nil
+	}
+	if p.order == nil {
+		return errors.New("Bad pcapng section header.")
+	}
+	kind = p.order.Uint32(header)
+	size := p.order.Uint32(header[4:])
+	if size < 12 || size%4 != 0 || size > maxPcapBlock {
+		return errors.New("Bad pcapng block length.")
+	}
+	body := make([]byte, size-8) // With the trailing length
+	if _, err := io.ReadFull(p.r, body); err != nil {
+		return unexpected(err)
+	}
+	body = body[:len(body)-4]
+
+	switch kind {
+	case 1: // Interface description
+		if len(body) < 8 {
+			return errors.New("Bad pcapng interface description.")
+		}
+		iface := pcapInterface{link: p.order.Uint16(body), perSecond: 1000000}
+		for opts := body[8:]; len(opts) >= 4; {
+			code, n := p.order.Uint16(opts), int(p.order.Uint16(opts[2:]))
+			if code == 0 || 4+n > len(opts) {
+				break
+			}
+			if code == 9 && n >= 1 { // if_tsresol
+				if v := opts[4]; v&0x80 == 0 {
+					iface.perSecond = uint64(math.Pow10(int(v)))
+				} else {
+					iface.perSecond = 1 << (v & 0x7F)
+				}
+			}
+			opts = opts[4+(n+3)/4*4:]
+		}
+		p.ifs = append(p.ifs, iface)
+	case 6, 2: // Enhanced packet, obsolete packet
+		if len(body) < 20 {
+			return errors.New("Bad pcapng packet block.")
+		}
+		id := p.order.Uint32(body)
+		if kind == 2 {
+			id = uint32(p.order.Uint16(body))
+		}
+		size := p.order.Uint32(body[12:])
+		if int(id) >= len(p.ifs) || 20+int64(size) > int64(len(body)) {
+			return errors.New("Bad pcapng packet block.")
+		}
+		iface := p.ifs[id]
+		units := uint64(p.order.Uint32(body[4:]))<<32 | uint64(p.order.Uint32(body[8:]))
+		p.packet(uint32(iface.link), iface.time(units), body[20:20+size])
+	case 3: // Simple packet, no timestamp
+		if len(body) < 4 || len(p.ifs) == 0 {
+			return errors.New("Bad pcapng packet block.")
+		}
+		data := body[4:]
+		if size := p.order.Uint32(body); int64(size) < int64(len(data)) {
+			data = data[:size]
+		}
+		p.packet(uint32(p.ifs[0].link), time.Time{}, data)
+	}
+	return nil
+}
+
+// time converts a timestamp in the units of the interface.
+func (i pcapInterface) time(units uint64) time.Time {
+	if i.perSecond == 0 || i.perSecond > 1e9 {
+		return time.Unix(0, int64(float64(units)/float64(i.perSecond)*1e9)).UTC()
+	}
+	sec, frac := units/i.perSecond, units%i.perSecond
+	return time.Unix(int64(sec), int64(frac*1e9/i.perSecond)).UTC()
+}
+
+// unexpected turns the end of the input inside a record into an error.
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// packet finds the UDP or TCP payload of a captured packet and queues its lines. Other
+// packets are ignored.
+func (p *PcapReader) packet(link uint32, ts time.Time, data []byte) {
+	var version int
+	switch link {
+	case linkEthernet:
+		if len(data) < 14 {
+			return
+		}
+		etherType := binary.BigEndian.Uint16(data[12:])
+		data = data[14:]
+		for (etherType == 0x8100 || etherType == 0x88A8) && len(data) >= 4 { // VLAN tags
+			etherType = binary.BigEndian.Uint16(data[2:])
+			data = data[4:]
+		}
+		version = etherTypeVersion(etherType)
+	case linkNull, linkLoop:
+		if len(data) < 4 {
+			return
+		}
+		family := binary.LittleEndian.Uint32(data)
+		if family > 0xFFFF {
+			family = binary.BigEndian.Uint32(data)
+		}
+		switch family {
+		case 2:
+			version = 4
+		case 10, 24, 28, 30:
+			version = 6
+		}
+		data = data[4:]
+	case linkSLL:
+		if len(data) < 16 {
+			return
+		}
+		version = etherTypeVersion(binary.BigEndian.Uint16(data[14:]))
+		data = data[16:]
+	case linkSLL2:
+		if len(data) < 20 {
+			return
+		}
+		version = etherTypeVersion(binary.BigEndian.Uint16(data))
+		data = data[20:]
+	case linkRaw, linkIPv4, linkIPv6:
+		if len(data) > 0 {
+			version = int(data[0] >> 4)
+		}
+	}
+
+	var src, dst net.IP
+	var protocol byte
+	switch version {
+	case 4:
+		if len(data) < 20 {
+			return
+		}
+		size, total := int(data[0]&0x0F)*4, int(binary.BigEndian.Uint16(data[2:]))
+		if size < 20 || total < size || total > len(data) {
+			return
+		}
+		if binary.BigEndian.Uint16(data[6:])&0x3FFF != 0 { // Fragment
+			return
+		}
+		protocol, src, dst = data[9], net.IP(data[12:16]), net.IP(data[16:20])
+		data = data[size:total]
+	case 6:
+		if len(data) < 40 {
+			return
+		}
+		total := 40 + int(binary.BigEndian.Uint16(data[4:]))
+		if total > len(data) {
+			return
+		}
+		protocol, src, dst = data[6], net.IP(data[8:24]), net.IP(data[24:40])
+		data = data[40:total]
+		for protocol == 0 || protocol == 43 || protocol == 60 { // Extension headers
+			if len(data) < 8 || int(data[1]+1)*8 > len(data) {
+				return
+			}
+			protocol, data = data[0], data[int(data[1]+1)*8:]
+		}
+	default:
+		return
+	}
+
+	switch protocol {
+	case 17: // UDP
+		if len(data) < 8 {
+			return
+		}
+		size := int(binary.BigEndian.Uint16(data[4:]))
+		if size < 8 || size > len(data) {
+			size = len(data)
+		}
+		source := net.JoinHostPort(src.String(), strconv.Itoa(int(binary.BigEndian.Uint16(data))))
+		var partial []byte
+		var skip bool
+		p.split(&partial, &skip, data[8:size], LineInfo{Timestamp: ts, Source: source})
+		p.flush(partial, skip, LineInfo{Timestamp: ts, Source: source})
+	case 6: // TCP
+		if len(data) < 20 || int(data[12]>>4)*4 > len(data) {
+			return
+		}
+		source := net.JoinHostPort(src.String(), strconv.Itoa(int(binary.BigEndian.Uint16(data))))
+		target := net.JoinHostPort(dst.String(), strconv.Itoa(int(binary.BigEndian.Uint16(data[2:]))))
+		p.segment(source+">"+target, binary.BigEndian.Uint32(data[4:]), data[13], data[int(data[12]>>4)*4:],
+			LineInfo{Timestamp: ts, Source: source})
+	}
+}
+
+// etherTypeVersion returns the IP version of an EtherType, or 0.
+func etherTypeVersion(etherType uint16) int {
+	switch etherType {
+	case 0x0800:
+		return 4
+	case 0x86DD:
+		return 6
+	}
+	return 0
+}
+
+// segment adds a TCP segment to its stream.
+func (p *PcapReader) segment(key string, seq uint32, flags byte, data []byte, info LineInfo) {
+	const fin, syn, rst = 1, 2, 4
+	s, ok := p.streams[key]
+	if !ok {
+		s = &pcapStream{next: seq, early: make(map[uint32][]byte)}
+		p.streams[key] = s
+	}
+	s.info = info
+	if flags&syn != 0 {
+		seq++
+		s.next = seq
+		s.partial, s.skip = nil, false
+	}
+
+	if gap := int32(seq - s.next); gap > 0 {
+		if len(data) > 0 {
+			s.early[seq] = append([]byte(nil), data...)
+		}
+		if len(s.early) > 16 { // Lost segment, continue from the earliest we have
+			first := true
+			for q := range s.early {
+				if first || int32(q-s.next) < int32(seq-s.next) {
+					seq, first = q, false
+				}
+			}
+			s.next, s.skip = seq, true
+			s.partial = nil
+			p.catchUp(s, info)
+		}
+	} else {
+		if -gap >= int32(len(data)) { // Retransmission
+			data = nil
+		} else {
+			data = data[-gap:]
+		}
+		p.add(s, data, info)
+		p.catchUp(s, info)
+	}
+
+	if flags&(fin|rst) != 0 {
+		p.flush(s.partial, s.skip, info)
+		delete(p.streams, key)
+	}
+}
+
+// add appends data in order to a stream.
+func (p *PcapReader) add(s *pcapStream, data []byte, info LineInfo) {
+	s.next += uint32(len(data))
+	p.split(&s.partial, &s.skip, data, info)
+}
+
+// catchUp adds the early segments that follow the stream.
+func (p *PcapReader) catchUp(s *pcapStream, info LineInfo) {
+	for {
+		data, ok := s.early[s.next]
+		if !ok {
+			return
+		}
+		delete(s.early, s.next)
+		p.add(s, data, info)
+	}
+}
+
+// split queues the complete lines of partial followed by data and keeps the rest in partial.
+// While skip is set, data are dropped up to the end of the line.
+func (p *PcapReader) split(partial *[]byte, skip *bool, data []byte, info LineInfo) {
+	max := p.MaxLineLength
+	if max <= 0 {
+		max = DefaultMaxLineLength
+	}
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if *skip { // Drop the rest of a cut or overlong line
+			if i < 0 {
+				return
+			}
+			data, *skip = data[i+1:], false
+			continue
+		}
+		if i < 0 {
+			if len(*partial)+len(data) > max {
+				p.failed(FailedSentence{string(append(*partial, data...)[:max]), "Line too long"})
+				*partial, *skip = nil, true
+				return
+			}
+			*partial = append(*partial, data...)
+			return
+		}
+		line := append(*partial, data[:i]...)
+		*partial = nil
+		data = data[i+1:]
+		p.flush(line, false, info)
+	}
+}
+
+// flush queues a line, unless it is empty or cut.
+func (p *PcapReader) flush(line []byte, cut bool, info LineInfo) {
+	if n := len(line); n > 0 && line[n-1] == '\r' {
+		line = line[:n-1]
+	}
+	if len(line) == 0 || cut {
+		return
+	}
+	p.lines = append(p.lines, pcapLine{string(line), info})
+}
+
+// flushStreams queues the last lines of the streams that didn't close, at the end of the
+// capture.
+func (p *PcapReader) flushStreams() {
+	keys := make([]string, 0, len(p.streams))
+	for k := range p.streams {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := p.streams[k]
+		p.flush(s.partial, s.skip, s.info)
+	}
+	p.streams = make(map[string]*pcapStream)
+}
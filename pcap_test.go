@@ -0,0 +1,271 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testPacket is a packet of a test capture.
+type testPacket struct {
+	ts   time.Time
+	data []byte
+}
+
+func testUDP(sport, dport uint16, data string) []byte {
+	b := make([]byte, 8, 8+len(data))
+	binary.BigEndian.PutUint16(b, sport)
+	binary.BigEndian.PutUint16(b[2:], dport)
+	binary.BigEndian.PutUint16(b[4:], uint16(8+len(data)))
+	return append(b, data...)
+}
+
+func testTCP(sport, dport uint16, seq uint32, flags byte, data string) []byte {
+	b := make([]byte, 20, 20+len(data))
+	binary.BigEndian.PutUint16(b, sport)
+	binary.BigEndian.PutUint16(b[2:], dport)
+	binary.BigEndian.PutUint32(b[4:], seq)
+	b[12], b[13] = 5<<4, flags|0x10
+	return append(b, data...)
+}
+
+func testIPv4(src, dst string, protocol byte, payload []byte) []byte {
+	b := make([]byte, 20, 20+len(payload))
+	b[0], b[9] = 0x45, protocol
+	binary.BigEndian.PutUint16(b[2:], uint16(20+len(payload)))
+	copy(b[12:], net.ParseIP(src).To4())
+	copy(b[16:], net.ParseIP(dst).To4())
+	return append(b, payload...)
+}
+
+func testIPv6(src, dst string, protocol byte, payload []byte) []byte {
+	b := make([]byte, 40, 40+len(payload))
+	b[0], b[6] = 0x60, protocol
+	binary.BigEndian.PutUint16(b[4:], uint16(len(payload)))
+	copy(b[8:], net.ParseIP(src))
+	copy(b[24:], net.ParseIP(dst))
+	return append(b, payload...)
+}
+
+func testEthernet(ip []byte) []byte {
+	b := make([]byte, 14, 14+len(ip))
+	binary.BigEndian.PutUint16(b[12:], 0x0800)
+	return append(b, ip...)
+}
+
+// testPcap writes a classic pcap capture.
+func testPcap(order binary.ByteOrder, nano bool, link uint32, packets []testPacket) []byte {
+	var buf bytes.Buffer
+	magic := uint32(0xA1B2C3D4)
+	if nano {
+		magic = 0xA1B23C4D
+	}
+	binary.Write(&buf, order, []uint32{magic, 2 | 4<<16, 0, 0, 65535, link})
+	for _, p := range packets {
+		frac := uint32(p.ts.Nanosecond())
+		if !nano {
+			frac /= 1000
+		}
+		binary.Write(&buf, order, []uint32{uint32(p.ts.Unix()), frac, uint32(len(p.data)), uint32(len(p.data))})
+		buf.Write(p.data)
+	}
+	return buf.Bytes()
+}
+
+// testPcapng writes a pcapng capture with one interface of nanosecond resolution.
+func testPcapng(order binary.ByteOrder, link uint16, packets []testPacket) []byte {
+	var buf bytes.Buffer
+	block := func(kind uint32, body []byte) {
+		for len(body)%4 != 0 {
+			body = append(body, 0)
+		}
+		binary.Write(&buf, order, []uint32{kind, uint32(12 + len(body))})
+		buf.Write(body)
+		binary.Write(&buf, order, uint32(12+len(body)))
+	}
+
+	var b bytes.Buffer
+	binary.Write(&b, order, []uint32{0x1A2B3C4D, 1})
+	binary.Write(&b, order, int64(-1))
+	block(0x0A0D0D0A, b.Bytes())
+
+	b.Reset()
+	binary.Write(&b, order, []uint16{link, 0})
+	binary.Write(&b, order, uint32(0))
+	binary.Write(&b, order, []uint16{9, 1}) // if_tsresol: 10^-9
+	b.Write([]byte{9, 0, 0, 0})
+	binary.Write(&b, order, []uint16{0, 0})
+	block(1, b.Bytes())
+
+	block(5, []byte{0, 0, 0, 0}) // Interface statistics, skipped
+	for _, p := range packets {
+		b.Reset()
+		ns := uint64(p.ts.UnixNano())
+		binary.Write(&b, order, []uint32{0, uint32(ns >> 32), uint32(ns), uint32(len(p.data)), uint32(len(p.data))})
+		b.Write(p.data)
+		block(6, b.Bytes())
+	}
+	return buf.Bytes()
+}
+
+func TestPcapReader(t *testing.T) {
+	t0 := time.Unix(1425772826, 123456000).UTC()
+	single := "!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F\r\n"
+	part1 := "!AIVDM,2,1,5,A,533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H,0*44\r\n"
+	part2 := "!AIVDM,2,2,5,A,51CU0E2CkP0,2*0C\r\n"
+	classB := "!AIVDM,1,1,,B,B3ujWF0000DdVU8O:1H03wi5oP06,0*5C\n"
+
+	tcp := func(seq uint32, flags byte, data string) []byte {
+		return testEthernet(testIPv4("10.0.0.2", "10.0.0.9", 6, testTCP(5000, 40000, seq, flags, data)))
+	}
+	packets := []testPacket{
+		{t0, testEthernet(testIPv4("10.0.0.1", "10.0.0.255", 17, testUDP(10110, 10110, single+classB)))},
+		{t0.Add(time.Second), tcp(999, 2, "")}, // SYN
+		{t0.Add(2 * time.Second), tcp(1000, 0, part1+part2[:10])},
+		{t0.Add(3 * time.Second), tcp(uint32(1000+len(part1)+len(part2)), 0, single[:20])}, // Early
+		{t0.Add(4 * time.Second), tcp(uint32(1000+len(part1)+10), 0, part2[10:])},
+		{t0.Add(5 * time.Second), tcp(1000, 0, part1)},                                        // Retransmission
+		{t0.Add(6 * time.Second), tcp(uint32(1000+len(part1)+len(part2)+20), 1, single[20:])}, // FIN
+		{t0.Add(7 * time.Second), testEthernet(testIPv4("10.0.0.1", "10.0.0.9", 1, []byte("ping")))},
+	}
+
+	long := "533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H51CU0E2CkP0"
+	want := []Message{
+		{Type: 3, Payload: "38u<a<?PAA2>P:WfuAO9PW<P0PuQ", Timestamp: t0, Source: "10.0.0.1:10110"},
+		{Type: 18, Payload: "B3ujWF0000DdVU8O:1H03wi5oP06", Timestamp: t0, Source: "10.0.0.1:10110"},
+		{Type: 5, Payload: long, Padding: 2, Timestamp: t0.Add(2 * time.Second), Source: "10.0.0.2:5000"},
+		{Type: 3, Payload: "38u<a<?PAA2>P:WfuAO9PW<P0PuQ", Timestamp: t0.Add(6 * time.Second), Source: "10.0.0.2:5000"},
+	}
+
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for _, nano := range []bool{false, true} {
+			p, err := NewPcapReader(bytes.NewReader(testPcap(order, nano, 1, packets)), RouterOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []Message
+			for {
+				m, err := p.Next()
+				if err != nil {
+					if err != io.EOF {
+						t.Errorf("PcapReader.Next() = %v", err)
+					}
+					break
+				}
+				got = append(got, m)
+			}
+			if !reflect.DeepEqual(got, want) {
+				fmt.Println("Got : ", got)
+				fmt.Println("Want: ", want)
+				t.Errorf("PcapReader.Next() of pcap, %v, nano %t", order, nano)
+			}
+		}
+	}
+}
+
+func TestPcapReaderPcapng(t *testing.T) {
+	t0 := time.Unix(1425772826, 123456789).UTC()
+	lines := "\\s:rx1,c:1425772826*0B\\!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F\r\n" +
+		"!AIVDM,1,1,,B,B3ujWF0000DdVU8O:1H03wi5oP06,0*5C"
+	packets := []testPacket{{t0, testIPv6("fe80::1", "ff02::1", 17, testUDP(2000, 10110, lines))}}
+	wantLines := []struct {
+		text string
+		info LineInfo
+	}{
+		{"\\s:rx1,c:1425772826*0B\\!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F", LineInfo{t0, "[fe80::1]:2000"}},
+		{"!AIVDM,1,1,,B,B3ujWF0000DdVU8O:1H03wi5oP06,0*5C", LineInfo{t0, "[fe80::1]:2000"}},
+	}
+
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		p, err := NewPcapReader(bytes.NewReader(testPcapng(order, 101, packets)), RouterOptions{Archive: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, w := range wantLines {
+			text, info, err := p.ReadLine()
+			if err != nil || text != w.text || info != w.info {
+				t.Errorf("PcapReader.ReadLine() = %q, %v, %v, want %q, %v", text, info, err, w.text, w.info)
+			}
+		}
+		if _, _, err := p.ReadLine(); err != io.EOF {
+			t.Errorf("PcapReader.ReadLine() = %v at the end, want io.EOF", err)
+		}
+	}
+
+	// The tag block overrides the capture time and the sender
+	p, _ := NewPcapReader(bytes.NewReader(testPcapng(binary.LittleEndian, 101, packets)), RouterOptions{Archive: true})
+	m, err := p.Next()
+	want := Message{Type: 3, Payload: "38u<a<?PAA2>P:WfuAO9PW<P0PuQ", Timestamp: time.Unix(1425772826, 0).UTC(), Source: "rx1"}
+	if err != nil || m != want {
+		t.Errorf("PcapReader.Next() = %v, %v, want %v", m, err, want)
+	}
+}
+
+func TestPcapReaderErrors(t *testing.T) {
+	if _, err := NewPcapReader(bytes.NewReader([]byte("!AIVDM,1,1")), RouterOptions{}); err == nil {
+		t.Errorf("NewPcapReader(r io.Reader, opts RouterOptions) accepted a text file")
+	}
+	capture := testPcap(binary.LittleEndian, false, 1, []testPacket{{time.Unix(0, 0), make([]byte, 60)}})
+	p, err := NewPcapReader(bytes.NewReader(capture[:len(capture)-1]), RouterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Next(); err != io.ErrUnexpectedEOF {
+		t.Errorf("PcapReader.Next() = %v for a cut capture, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestPcapReaderLongLine(t *testing.T) {
+	t0 := time.Unix(1425772826, 0).UTC()
+	single := "!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F\r\n"
+	long := "!AIVDM," + strings.Repeat("0", 100)
+	tcp := func(seq uint32, flags byte, data string) []byte {
+		return testEthernet(testIPv4("10.0.0.2", "10.0.0.9", 6, testTCP(5000, 40000, seq, flags, data)))
+	}
+	packets := []testPacket{
+		{t0, tcp(999, 2, "")},
+		{t0, tcp(1000, 0, long[:60])},
+		{t0, tcp(1060, 0, long[60:])},
+		{t0, tcp(uint32(1000+len(long)), 1, "\r\n"+single)},
+	}
+
+	p, err := NewPcapReader(bytes.NewReader(testPcap(binary.LittleEndian, false, 1, packets)), RouterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.MaxLineLength = 50
+	var failed []FailedSentence
+	p.Failed = func(f FailedSentence) { failed = append(failed, f) }
+	if m, err := p.Next(); err != nil || m.Payload != "38u<a<?PAA2>P:WfuAO9PW<P0PuQ" {
+		t.Errorf("PcapReader.Next() = %v, %v", m, err)
+	}
+	if _, err := p.Next(); err != io.EOF {
+		t.Errorf("PcapReader.Next() = %v at the end, want io.EOF", err)
+	}
+	if len(failed) != 1 || failed[0].Issue != "Line too long" || failed[0].Sentence != long[:50] {
+		t.Errorf("PcapReader.Failed got %v, want the start of the long line", failed)
+	}
+}
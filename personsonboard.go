@@ -0,0 +1,45 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"errors"
+)
+
+// PersonsOnBoard is a DAC 1, FID 16 (addressed) or FID 40 (broadcast) message.
+type PersonsOnBoard struct {
+	BinaryHeader
+	Persons uint16 // 0: not available, 8191: 8191 or more
+}
+
+// DecodePersonsOnBoard decodes the payload of a Number of Persons on Board message
+// (type 6 DAC 1 FID 16 or type 8 DAC 1 FID 40).
+func DecodePersonsOnBoard(payload string) (PersonsOnBoard, error) {
+	r := NewBitReader(payload)
+	var m PersonsOnBoard
+	var start int
+
+	m.BinaryHeader, start = decodeBinaryHeader(r)
+	if m.DAC != 1 || !(m.Type == 6 && m.FID == 16 || m.Type == 8 && m.FID == 40) {
+		return m, errors.New("Message isn't Number of Persons on Board (DAC 1, FID 16 or 40).")
+	}
+
+	m.Persons = uint16(r.Uint(start, start+12))
+
+	return m, nil
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDecodePersonsOnBoard(t *testing.T) {
+	cases := []struct {
+		payload string
+		want    PersonsOnBoard
+	}{
+		{
+			"63aEOK00U@i0050;00",
+			PersonsOnBoard{
+				BinaryHeader: BinaryHeader{Type: 6, Repeat: 0, MMSI: 244670316, DestMMSI: 2442000, DAC: 1, FID: 16},
+				Persons:      352},
+		},
+		{
+			"83aEOK00J2G@",
+			PersonsOnBoard{
+				BinaryHeader: BinaryHeader{Type: 8, Repeat: 0, MMSI: 244670316, DAC: 1, FID: 40},
+				Persons:      1210},
+		},
+	}
+	for _, c := range cases {
+		got, _ := DecodePersonsOnBoard(c.payload)
+		if got != c.want {
+			fmt.Println("Got : ", got)
+			fmt.Println("Want: ", c.want)
+			t.Errorf("DecodePersonsOnBoard(payload string)")
+		}
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Port operations are handled with DAC 1 Addressed Binary messages (type 6), exchanged
+// between ships and port authorities.
+
+// CapabilityInterrogation is a DAC 1, FID 2 message. It asks a station which functional
+// messages (FIDs) of a DAC it supports.
+type CapabilityInterrogation struct {
+	BinaryHeader
+	RequestedDAC uint16
+}
+
+// CapabilityReply is a DAC 1, FID 3 message.
+type CapabilityReply struct {
+	BinaryHeader
+	RequestedDAC uint16
+	Available    [64]bool // Availability of each FID
+}
+
+// DangerousCargo is a DAC 1, FID 12 message.
+type DangerousCargo struct {
+	BinaryHeader
+	LastPort  string    // UN/LOCODE of last port of call
+	Departure time.Time // UTC, actual time of departure, year isn't transmitted
+	NextPort  string    // UN/LOCODE of next port of call
+	ETA       time.Time // UTC, year isn't transmitted
+	Good      string    // Main dangerous good
+	IMDClass  string    // IMD category
+	UNNumber  uint16
+	Amount    uint16 // Amount of cargo
+	Unit      uint8  // Unit of amount, 0: not available, 1: kg, 2: tonnes, 3: 1000 tonnes
+}
+
+// TidalWindow is a period when a point of a fairway can be passed.
+type TidalWindow struct {
+	Lon          float64
+	Lat          float64
+	From         time.Time // UTC, hour and minute
+	To           time.Time // UTC, hour and minute
+	CurrentDir   uint16    // Direction of current in degrees, 360: not available
+	CurrentSpeed uint8     // Knots/10
+}
+
+// TidalWindowMessage is a DAC 1, FID 14 (IMO 236) or FID 32 (IMO 289) message.
+type TidalWindowMessage struct {
+	BinaryHeader
+	Month   uint8
+	Day     uint8
+	Windows []TidalWindow
+}
+
+// ClearanceTime is a DAC 1, FID 18 message. It informs a ship when it may enter a port.
+type ClearanceTime struct {
+	BinaryHeader
+	LinkageID   uint16    // Message linkage ID
+	Time        time.Time // UTC, clearance time, year isn't transmitted
+	PortName    string
+	Destination string // UN/LOCODE
+	Lon         float64
+	Lat         float64
+}
+
+// BerthingData is a DAC 1, FID 20 message.
+type BerthingData struct {
+	BinaryHeader
+	LinkageID uint16    // Message linkage ID
+	Length    uint16    // Berth length in meters
+	Depth     uint8     // Water depth at berth, meters/10
+	Position  uint8     // Mooring position, enumerated, see MooringPositions
+	Time      time.Time // UTC, year isn't transmitted
+	Services  bool      // true if the service statuses below are available
+	Status    [26]uint8 // Status of each service, 0: not available, 1: available, 2: not available at this berth, 3: unknown
+	BerthName string
+	BerthLon  float64
+	BerthLat  float64
+}
+
+// The services whose status BerthingData reports, in order
+var BerthingServices = [...]string{
+	"Agent", "Bunker/fuel", "Chandler", "Stevedore", "Electrical", "Potable water", "Customs house",
+	"Cartage", "Crane(s)", "Lift(s)", "Medical facilities", "Navigation repair", "Provisions",
+	"Ship repair", "Surveyor", "Steam", "Tugs", "Waste disposal (solid)", "Waste disposal (liquid)",
+	"Waste disposal (hazardous)", "Reserved ballast exchange", "Additional services",
+	"Regional reserved 1", "Regional reserved 2", "Reserved for future", "Reserved for future",
+}
+
+var MooringPositions = [...]string{
+	"Not available", "Port-side to", "Starboard-side to", "Mediterranean (end-on) mooring",
+	"Mooring buoy", "Anchorage", "reserved", "reserved",
+}
+
+// decodePortOperation decodes the header of an addressed DAC 1 message and checks its FID.
+func decodePortOperation(r *BitReader, fids ...uint8) (BinaryHeader, bool) {
+	h, _ := decodeBinaryHeader(r)
+	if h.Type != 6 || h.DAC != 1 {
+		return h, false
+	}
+	for _, fid := range fids {
+		if h.FID == fid {
+			return h, true
+		}
+	}
+	return h, false
+}
+
+// DecodeCapabilityInterrogation decodes the payload of a Capability Interrogation message (type 6 DAC 1 FID 2).
+func DecodeCapabilityInterrogation(payload string) (CapabilityInterrogation, error) {
+	r := NewBitReader(payload)
+	var m CapabilityInterrogation
+	var ok bool
+
+	m.BinaryHeader, ok = decodePortOperation(r, 2)
+	if !ok {
+		return m, errors.New("Message isn't Capability Interrogation (type 6, DAC 1, FID 2).")
+	}
+
+	m.RequestedDAC = uint16(r.Uint(88, 97))
+
+	return m, nil
+}
+
+// DecodeCapabilityReply decodes the payload of a Capability Reply message (type 6 DAC 1 FID 3).
+func DecodeCapabilityReply(payload string) (CapabilityReply, error) {
+	r := NewBitReader(payload)
+	var m CapabilityReply
+	var ok bool
+
+	m.BinaryHeader, ok = decodePortOperation(r, 3)
+	if !ok {
+		return m, errors.New("Message isn't Capability Reply (type 6, DAC 1, FID 3).")
+	}
+
+	m.RequestedDAC = uint16(r.Uint(88, 97))
+
+	// Two bits per FID, the first one is the availability flag, the second is reserved
+	for i := range m.Available {
+		m.Available[i] = r.Bool(98 + i*2)
+	}
+
+	return m, nil
+}
+
+// DecodeDangerousCargo decodes the payload of a Dangerous Cargo Indication message (type 6 DAC 1 FID 12).
+func DecodeDangerousCargo(payload string) (DangerousCargo, error) {
+	r := NewBitReader(payload)
+	var m DangerousCargo
+	var ok bool
+
+	m.BinaryHeader, ok = decodePortOperation(r, 12)
+	if !ok {
+		return m, errors.New("Message isn't Dangerous Cargo Indication (type 6, DAC 1, FID 12).")
+	}
+
+	m.LastPort = r.String(88, 117)
+	m.Departure = cbnMonthDayTime(118, r)
+	m.NextPort = r.String(138, 167)
+	m.ETA = cbnMonthDayTime(168, r)
+
+	m.Good = r.String(188, 307)
+	m.IMDClass = r.String(308, 331)
+	m.UNNumber = uint16(r.Uint(332, 344))
+	m.Amount = uint16(r.Uint(345, 354))
+	m.Unit = uint8(r.Uint(355, 356))
+
+	return m, nil
+}
+
+// DecodeTidalWindow decodes the payload of a Tidal Window message (type 6 DAC 1 FID 14 or 32).
+// The two versions have different layouts for their windows.
+func DecodeTidalWindow(payload string) (TidalWindowMessage, error) {
+	r := NewBitReader(payload)
+	var m TidalWindowMessage
+	var ok bool
+
+	m.BinaryHeader, ok = decodePortOperation(r, 14, 32)
+	if !ok {
+		return m, errors.New("Message isn't Tidal Window (type 6, DAC 1, FID 14 or 32).")
+	}
+
+	m.Month = uint8(r.Uint(88, 91))
+	m.Day = uint8(r.Uint(92, 96))
+
+	decode, size := decodeTidalWindow32, 88
+	if m.FID == 14 {
+		decode, size = decodeTidalWindow14, 93
+	}
+
+	// Up to three windows
+	for first := 97; first+size <= r.Len() && len(m.Windows) < 3; first += size {
+		m.Windows = append(m.Windows, decode(first, r))
+	}
+
+	return m, nil
+}
+
+// decodeTidalWindow14 decodes a window of FID 14 (IMO 236): latitude (27 bits) before
+// longitude (28 bits), in 1/10000 minutes.
+func decodeTidalWindow14(first int, r *BitReader) TidalWindow {
+	var w TidalWindow
+	lat := float64(r.Int(first, first+26))
+	lon := float64(r.Int(first+27, first+54))
+	w.Lon, w.Lat = CoordinatesMin2Deg(lon, lat)
+	w.From, _ = time.Parse("15:4", fmt.Sprintf("%d:%d", r.Uint(first+55, first+59),
+		r.Uint(first+60, first+65)))
+	w.To, _ = time.Parse("15:4", fmt.Sprintf("%d:%d", r.Uint(first+66, first+70),
+		r.Uint(first+71, first+76)))
+	w.CurrentDir = uint16(r.Uint(first+77, first+85))
+	w.CurrentSpeed = uint8(r.Uint(first+86, first+92))
+	return w
+}
+
+// decodeTidalWindow32 decodes a window of FID 32 (IMO 289): low resolution coordinates.
+func decodeTidalWindow32(first int, r *BitReader) TidalWindow {
+	var w TidalWindow
+	w.Lon, w.Lat = cbnCoordinatesLow(first, r)
+	w.From, _ = time.Parse("15:4", fmt.Sprintf("%d:%d", r.Uint(first+49, first+53),
+		r.Uint(first+54, first+59)))
+	w.To, _ = time.Parse("15:4", fmt.Sprintf("%d:%d", r.Uint(first+60, first+64),
+		r.Uint(first+65, first+70)))
+	w.CurrentDir = uint16(r.Uint(first+71, first+79))
+	w.CurrentSpeed = uint8(r.Uint(first+80, first+87))
+	return w
+}
+
+// DecodeClearanceTime decodes the payload of a Clearance Time to Enter Port message (type 6 DAC 1 FID 18).
+func DecodeClearanceTime(payload string) (ClearanceTime, error) {
+	r := NewBitReader(payload)
+	var m ClearanceTime
+	var ok bool
+
+	m.BinaryHeader, ok = decodePortOperation(r, 18)
+	if !ok {
+		return m, errors.New("Message isn't Clearance Time to Enter Port (type 6, DAC 1, FID 18).")
+	}
+
+	m.LinkageID = uint16(r.Uint(88, 97))
+	m.Time = cbnMonthDayTime(98, r)
+	m.PortName = r.String(118, 237)
+	m.Destination = r.String(238, 267)
+	m.Lon, m.Lat = cbnCoordinatesLow(268, r)
+
+	return m, nil
+}
+
+// DecodeBerthingData decodes the payload of a Berthing Data message (type 6 DAC 1 FID 20).
+func DecodeBerthingData(payload string) (BerthingData, error) {
+	r := NewBitReader(payload)
+	var m BerthingData
+	var ok bool
+
+	m.BinaryHeader, ok = decodePortOperation(r, 20)
+	if !ok {
+		return m, errors.New("Message isn't Berthing Data (type 6, DAC 1, FID 20).")
+	}
+
+	m.LinkageID = uint16(r.Uint(88, 97))
+	m.Length = uint16(r.Uint(98, 106))
+	m.Depth = uint8(r.Uint(107, 114))
+	m.Position = uint8(r.Uint(115, 117))
+	m.Time = cbnMonthDayTime(118, r)
+
+	m.Services = r.Bool(138)
+	for i := range m.Status {
+		m.Status[i] = uint8(r.Uint(139+i*2, 140+i*2))
+	}
+
+	m.BerthName = r.String(191, 310)
+	m.BerthLon, m.BerthLat = cbnCoordinatesLow(311, r)
+
+	return m, nil
+}
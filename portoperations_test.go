@@ -0,0 +1,143 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecodeCapability(t *testing.T) {
+	wantQ := CapabilityInterrogation{
+		BinaryHeader: BinaryHeader{Type: 6, Repeat: 0, MMSI: 2442000, DestMMSI: 244670316, DAC: 1, FID: 2},
+		RequestedDAC: 1,
+	}
+	gotQ, _ := DecodeCapabilityInterrogation("602E340rEGnh0480@0")
+	if gotQ != wantQ {
+		fmt.Println("Got : ", gotQ)
+		fmt.Println("Want: ", wantQ)
+		t.Errorf("DecodeCapabilityInterrogation(payload string)")
+	}
+
+	wantR := CapabilityReply{
+		BinaryHeader: BinaryHeader{Type: 6, Repeat: 0, MMSI: 244670316, DestMMSI: 2442000, DAC: 1, FID: 3},
+		RequestedDAC: 1,
+	}
+	wantR.Available[0], wantR.Available[16], wantR.Available[21], wantR.Available[40] = true, true, true, true
+	gotR, _ := DecodeCapabilityReply("63aEOK00U@i004<0H0000208000002000000000")
+	if gotR != wantR {
+		fmt.Println("Got : ", gotR)
+		fmt.Println("Want: ", wantR)
+		t.Errorf("DecodeCapabilityReply(payload string)")
+	}
+}
+
+func TestDecodeDangerousCargo(t *testing.T) {
+	departure, _ := time.Parse("1/2 15:4", "3/14 6:30")
+	eta, _ := time.Parse("1/2 15:4", "3/15 8:0")
+	want := DangerousCargo{
+		BinaryHeader: BinaryHeader{Type: 6, Repeat: 0, MMSI: 244670316, DestMMSI: 2442000, DAC: 1, FID: 12},
+		LastPort:     "NLRTM", Departure: departure, NextPort: "GBFXT", ETA: eta, Good: "ACETONE",
+		IMDClass: "3", UNNumber: 1090, Amount: 250, Unit: 2,
+	}
+	got, _ := DecodeDangerousCargo("63aEOK00U@i004hpi9@lo6N726HD=r00@iE3kQ@000000000000<h0028Au@")
+	if got != want {
+		fmt.Println("Got : ", got)
+		fmt.Println("Want: ", want)
+		t.Errorf("DecodeDangerousCargo(payload string)")
+	}
+}
+
+func TestDecodeTidalWindow(t *testing.T) {
+	from1, _ := time.Parse("15:4", "10:30")
+	to1, _ := time.Parse("15:4", "12:0")
+	from2, _ := time.Parse("15:4", "22:45")
+	to2, _ := time.Parse("15:4", "23:59")
+	cases := []struct {
+		payload string
+		want    TidalWindowMessage
+	}{
+		{
+			// Built from the IMO 236 layout: latitude (27 bits) then longitude (28 bits), 93 bits a window
+			"602E340rEGnh04po7Kq202DD<5?<0;ATsOo8Oe>m1JnwL>wP",
+			TidalWindowMessage{
+				BinaryHeader: BinaryHeader{Type: 6, Repeat: 0, MMSI: 2442000, DestMMSI: 244670316, DAC: 1, FID: 14},
+				Month:        3, Day: 14,
+				Windows: []TidalWindow{
+					{Lon: 4.05, Lat: 51.98, From: from1, To: to1, CurrentDir: 90, CurrentSpeed: 25},
+					{Lon: -4.1, Lat: 51.99, From: from2, To: to2, CurrentDir: 270, CurrentSpeed: 127},
+				},
+			},
+		},
+		{
+			"602E340rEGnh060o0>m>2vFl5?<0;@j0t3h;qT`eKOf7@@",
+			TidalWindowMessage{
+				BinaryHeader: BinaryHeader{Type: 6, Repeat: 0, MMSI: 2442000, DestMMSI: 244670316, DAC: 1, FID: 32},
+				Month:        3, Day: 14,
+				Windows: []TidalWindow{
+					{Lon: 4.05, Lat: 51.98, From: from1, To: to1, CurrentDir: 90, CurrentSpeed: 25},
+					{Lon: 4.1, Lat: 51.99, From: from2, To: to2, CurrentDir: 270, CurrentSpeed: 130},
+				},
+			},
+		},
+	}
+	for _, c := range cases {
+		got, _ := DecodeTidalWindow(c.payload)
+		if !reflect.DeepEqual(got, c.want) {
+			fmt.Println("Got : ", got)
+			fmt.Println("Want: ", c.want)
+			t.Errorf("DecodeTidalWindow(payload string)")
+		}
+	}
+}
+
+func TestDecodeClearanceTime(t *testing.T) {
+	clearance, _ := time.Parse("1/2 15:4", "3/14 16:15")
+	want := ClearanceTime{
+		BinaryHeader: BinaryHeader{Type: 6, Repeat: 0, MMSI: 2442000, DestMMSI: 244670316, DAC: 1, FID: 18},
+		LinkageID:    7, Time: clearance, PortName: "ROTTERDAM", Destination: "NLRTM", Lon: 4.05, Lat: 51.98,
+	}
+	got, _ := DecodeClearanceTime("602E340rEGnh0581kM0u8uA@E8@4l00000000000pi9@l1nahGjnP0000000")
+	if got != want {
+		fmt.Println("Got : ", got)
+		fmt.Println("Want: ", want)
+		t.Errorf("DecodeClearanceTime(payload string)")
+	}
+}
+
+func TestDecodeBerthingData(t *testing.T) {
+	berthing, _ := time.Parse("1/2 15:4", "3/14 17:0")
+	want := BerthingData{
+		BinaryHeader: BinaryHeader{Type: 6, Repeat: 0, MMSI: 2442000, DestMMSI: 244670316, DAC: 1, FID: 20},
+		LinkageID:    9, Length: 250, Depth: 145, Position: 1, Time: berthing, Services: true,
+		Status:    [26]uint8{1, 2, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 0, 0},
+		BerthName: "AMAZONEHAVEN 8", BerthLon: 4.0367, BerthLat: 51.9575,
+	}
+	got, _ := DecodeBerthingData("602E340rEGnh05@2Gm8ToA0dbbbbbbbP2J2lNL:@2d:M1h0000000s8J;q6:")
+	if got != want {
+		fmt.Println("Got : ", got)
+		fmt.Println("Want: ", want)
+		t.Errorf("DecodeBerthingData(payload string)")
+	}
+
+	if _, err := DecodeBerthingData("602E340rEGnh0581kM0u8uA@E8@4l00000000000pi9@l1nahGjnP0000000"); err == nil {
+		t.Errorf("DecodeBerthingData(payload string) accepted a Clearance Time message")
+	}
+}
@@ -85,10 +85,10 @@ var NavigationStatusCodes = [...]string{
 
 // DecodeClassAPositionReport decodes [the payload of] an AIS position message (type 1/2/3)
 func DecodeClassAPositionReport(payload string) (ClassAPositionReport, error) {
-	data := []byte(payload)
+	r := NewBitReader(payload)
 	var m ClassAPositionReport
 
-	m.Type = decodeAisChar(data[0])
+	m.Type = uint8(r.Uint(0, 5))
 	if m.Type != 1 && m.Type != 2 && m.Type != 3 {
 		return m, errors.New("Message isn't Class A Position Report (type 1, 2 or 3).")
 	}
@@ -98,18 +98,18 @@ func DecodeClassAPositionReport(payload string) (ClassAPositionReport, error) {
 	// into binary field decoding.
 
 	//m.Repeat = decodeAisChar(data[1]) >> 4
-	m.Repeat = uint8(bitsToInt(6, 7, data))
+	m.Repeat = uint8(r.Uint(6, 7))
 
 	//m.MMSI = uint32(decodeAisChar(data[1]))<<28>>2 | uint32(decodeAisChar(data[2]))<<20 |
 	//	uint32(decodeAisChar(data[3]))<<14 | uint32(decodeAisChar(data[4]))<<8 |
 	//	uint32(decodeAisChar(data[5]))<<2 | uint32(decodeAisChar(data[6]))>>4
-	m.MMSI = bitsToInt(8, 37, data)
+	m.MMSI = uint32(r.Uint(8, 37))
 
 	//m.Status = (decodeAisChar(data[6]) << 4) >> 4
-	m.Status = uint8(bitsToInt(38, 41, data))
+	m.Status = uint8(r.Uint(38, 41))
 
 	//m.Turn = float32(int8(decodeAisChar(data[7])<<2 | decodeAisChar(data[8])>>4))
-	m.Turn = float32(int8(bitsToInt(42, 49, data)))
+	m.Turn = float32(r.Int(42, 49))
 	if m.Turn != 0 && m.Turn <= 126 && m.Turn >= -126 {
 		sign := float32(1)
 		if math.Signbit(float64(m.Turn)) {
@@ -120,13 +120,13 @@ func DecodeClassAPositionReport(payload string) (ClassAPositionReport, error) {
 	}
 
 	//m.Speed = float32(uint16(decodeAisChar(data[8]))<<12>>6 | uint16(decodeAisChar(data[9])))
-	m.Speed = cbnSpeed(50, data)
+	m.Speed = cbnSpeed(50, r)
 
 	//m.Accuracy = false
 	//if decodeAisChar(data[10])>>5 == 1 {
 	//	m.Accuracy = true
 	//}
-	m.Accuracy = cbnBool(60, data)
+	m.Accuracy = r.Bool(60)
 
 	// Old method 1
 	//m.Lon = float64((int32(decodeAisChar(data[10]))<<27 | int32(decodeAisChar(data[11]))<<21 |
@@ -140,111 +140,177 @@ func DecodeClassAPositionReport(payload string) (ClassAPositionReport, error) {
 	//m.Lat = float64((int32(bitsToInt(89, 115, data)) << 5)) / 32
 	// Finish or both old methods
 	//m.Lon, m.Lat = CoordinatesMin2Deg(m.Lon, m.Lat)
-	m.Lon, m.Lat = cbnCoordinates(61, data)
+	m.Lon, m.Lat = cbnCoordinates(61, r)
 
 	//m.Course = float32(uint16(decodeAisChar(data[19]))<<12>>4|uint16(decodeAisChar(data[20]))<<2|
 	//	uint16(decodeAisChar(data[21]))>>4) / 10
-	m.Course = float32(bitsToInt(116, 127, data)) / 10
+	m.Course = float32(r.Uint(116, 127)) / 10
 
 	//m.Heading = uint16(decodeAisChar(data[21]))<<12>>7 | uint16(decodeAisChar(data[22]))>>1
-	m.Heading = uint16(bitsToInt(128, 136, data))
+	m.Heading = uint16(r.Uint(128, 136))
 
 	//m.Second = decodeAisChar(data[22])<<7>>2 | decodeAisChar(data[23])>>1
-	m.Second = uint8(bitsToInt(137, 142, data))
+	m.Second = uint8(r.Uint(137, 142))
 
 	//m.Maneuver = decodeAisChar(data[23])<<7>>6 | decodeAisChar(data[24])>>5
-	m.Maneuver = uint8(bitsToInt(143, 144, data))
+	m.Maneuver = uint8(r.Uint(143, 144))
 
 	//m.RAIM = false
 	//if decodeAisChar(data[24])<<6>>7 == 1 {
 	//	m.RAIM = true
 	//}
-	m.RAIM = cbnBool(148, data)
+	m.RAIM = r.Bool(148)
 
-	m.Radio = bitsToInt(149, 167, data)
+	m.Radio = uint32(r.Uint(149, 167))
 	return m, nil
 }
 
+// EncodeClassAPositionReport encodes a Class A Position Report (type 1, 2 or 3) to an AIS
+// message, the inverse of DecodeClassAPositionReport.
+func EncodeClassAPositionReport(m ClassAPositionReport) (Message, error) {
+	if m.Type != 1 && m.Type != 2 && m.Type != 3 {
+		return Message{}, errors.New("Message isn't Class A Position Report (type 1, 2 or 3).")
+	}
+	w := NewBitWriter(168)
+
+	w.Uint(0, 5, uint64(m.Type))
+	w.Uint(6, 7, uint64(m.Repeat))
+	w.Uint(8, 37, uint64(m.MMSI))
+	w.Uint(38, 41, uint64(m.Status))
+
+	// Rate of turn, special values (0, ±127, -128) are written as they are
+	turn := float64(m.Turn)
+	if turn != 0 && turn != 127 && turn != -127 && turn != -128 {
+		raw := math.Min(math.Round(4.733*math.Sqrt(math.Abs(turn))), 126)
+		turn = math.Copysign(raw, turn)
+	}
+	w.Int(42, 49, int64(turn))
+
+	cbnPutSpeed(50, w, m.Speed)
+	w.Bool(60, m.Accuracy)
+	cbnPutCoordinates(61, w, m.Lon, m.Lat)
+	w.Uint(116, 127, uint64(math.Round(float64(m.Course)*10)))
+	w.Uint(128, 136, uint64(m.Heading))
+	w.Uint(137, 142, uint64(m.Second))
+	w.Uint(143, 144, uint64(m.Maneuver))
+	w.Bool(148, m.RAIM)
+	w.Uint(149, 167, uint64(m.Radio))
+
+	payload, padding := w.Payload()
+	return Message{Type: m.Type, Payload: payload, Padding: padding}, nil
+}
+
 // DecodeClassBPositionReport decodes [the payload of] an AIS position message (type 18)
 func DecodeClassBPositionReport(payload string) (ClassBPositionReport, error) {
-	data := []byte(payload)
+	r := NewBitReader(payload)
 	var m ClassBPositionReport
 
-	m.Type = decodeAisChar(data[0])
+	m.Type = uint8(r.Uint(0, 5))
 	if m.Type != 18 {
 		return m, errors.New("Message isn't Class B Position Report (type 18).")
 	}
 
-	m.Repeat = uint8(bitsToInt(6, 7, data))
+	m.Repeat = uint8(r.Uint(6, 7))
 
-	m.MMSI = bitsToInt(8, 37, data)
+	m.MMSI = uint32(r.Uint(8, 37))
 
-	m.Speed = cbnSpeed(46, data)
+	m.Speed = cbnSpeed(46, r)
 
-	m.Accuracy = cbnBool(56, data)
+	m.Accuracy = r.Bool(56)
 
-	m.Lon, m.Lat = cbnCoordinates(57, data)
+	m.Lon, m.Lat = cbnCoordinates(57, r)
 
-	m.Course = float32(bitsToInt(112, 123, data)) / 10
+	m.Course = float32(r.Uint(112, 123)) / 10
 
-	m.Heading = uint16(bitsToInt(124, 132, data))
+	m.Heading = uint16(r.Uint(124, 132))
 
-	m.Second = uint8(bitsToInt(133, 138, data))
+	m.Second = uint8(r.Uint(133, 138))
 
-	m.CSUnit = cbnBool(141, data)
-	m.Display = cbnBool(142, data)
-	m.DSC = cbnBool(143, data)
-	m.Band = cbnBool(144, data)
-	m.Msg22 = cbnBool(145, data)
-	m.Assigned = cbnBool(146, data)
+	m.CSUnit = r.Bool(141)
+	m.Display = r.Bool(142)
+	m.DSC = r.Bool(143)
+	m.Band = r.Bool(144)
+	m.Msg22 = r.Bool(145)
+	m.Assigned = r.Bool(146)
 
-	m.RAIM = cbnBool(147, data)
-	if decodeAisChar(data[24])<<6>>7 == 1 {
+	m.RAIM = r.Bool(147)
+	if r.Bool(148) {
 		m.RAIM = true
 	}
 
-	m.Radio = bitsToInt(148, 167, data)
+	m.Radio = uint32(r.Uint(148, 167))
 	return m, nil
 }
 
+// EncodeClassBPositionReport encodes a Class B Position Report (type 18) to an AIS message, the
+// inverse of DecodeClassBPositionReport. Radio holds the communication state selector flag
+// (bit 148) too, so when it is set the decoder reports RAIM as set.
+func EncodeClassBPositionReport(m ClassBPositionReport) (Message, error) {
+	if m.Type != 18 {
+		return Message{}, errors.New("Message isn't Class B Position Report (type 18).")
+	}
+	w := NewBitWriter(168)
+
+	w.Uint(0, 5, uint64(m.Type))
+	w.Uint(6, 7, uint64(m.Repeat))
+	w.Uint(8, 37, uint64(m.MMSI))
+	cbnPutSpeed(46, w, m.Speed)
+	w.Bool(56, m.Accuracy)
+	cbnPutCoordinates(57, w, m.Lon, m.Lat)
+	w.Uint(112, 123, uint64(math.Round(float64(m.Course)*10)))
+	w.Uint(124, 132, uint64(m.Heading))
+	w.Uint(133, 138, uint64(m.Second))
+	w.Bool(141, m.CSUnit)
+	w.Bool(142, m.Display)
+	w.Bool(143, m.DSC)
+	w.Bool(144, m.Band)
+	w.Bool(145, m.Msg22)
+	w.Bool(146, m.Assigned)
+	w.Bool(147, m.RAIM)
+	w.Uint(148, 167, uint64(m.Radio))
+
+	payload, padding := w.Payload()
+	return Message{Type: m.Type, Payload: payload, Padding: padding}, nil
+}
+
 // DecodeExtendedClassBPositionReport decodes [the payload of] an AIS extendedposition message (type 19)
 func DecodeExtendedClassBPositionReport(payload string) (ExtendedClassBPositionReport, error) {
-	data := []byte(payload)
+	r := NewBitReader(payload)
 	var m ExtendedClassBPositionReport
 
-	m.Type = decodeAisChar(data[0])
+	m.Type = uint8(r.Uint(0, 5))
 	if m.Type != 19 {
 		return m, errors.New("Message isn't Extended Class B Position Report (type 18).")
 	}
 
-	m.Repeat = uint8(bitsToInt(6, 7, data))
+	m.Repeat = uint8(r.Uint(6, 7))
 
-	m.MMSI = bitsToInt(8, 37, data)
+	m.MMSI = uint32(r.Uint(8, 37))
 
-	m.Speed = cbnSpeed(46, data)
+	m.Speed = cbnSpeed(46, r)
 
-	m.Accuracy = cbnBool(56, data)
+	m.Accuracy = r.Bool(56)
 
-	m.Lon, m.Lat = cbnCoordinates(57, data)
+	m.Lon, m.Lat = cbnCoordinates(57, r)
 
-	m.Course = float32(bitsToInt(112, 123, data)) / 10
+	m.Course = float32(r.Uint(112, 123)) / 10
 
-	m.Heading = uint16(bitsToInt(124, 132, data))
+	m.Heading = uint16(r.Uint(124, 132))
 
-	m.Second = uint8(bitsToInt(133, 138, data))
+	m.Second = uint8(r.Uint(133, 138))
 
-	m.VesselName = bitsToString(143, 262, data)
+	m.VesselName = r.String(143, 262)
 
-	m.ShipType = uint8(bitsToInt(263, 270, data))
+	m.ShipType = uint8(r.Uint(263, 270))
 
-	m.ToBow = uint16(bitsToInt(271, 279, data))
-	m.ToStern = uint16(bitsToInt(280, 288, data))
-	m.ToPort = uint8(bitsToInt(289, 294, data))
-	m.ToStarboard = uint8(bitsToInt(295, 300, data))
+	m.ToBow = uint16(r.Uint(271, 279))
+	m.ToStern = uint16(r.Uint(280, 288))
+	m.ToPort = uint8(r.Uint(289, 294))
+	m.ToStarboard = uint8(r.Uint(295, 300))
 
-	m.EPFD = uint8(bitsToInt(301, 304, data))
-	m.RAIM = cbnBool(147, data)
-	if decodeAisChar(data[24])<<6>>7 == 1 {
+	m.EPFD = uint8(r.Uint(301, 304))
+	m.RAIM = r.Bool(147)
+	if r.Bool(148) {
 		m.RAIM = true
 	}
 	return m, nil
@@ -36,8 +36,8 @@ func TestDecodeClassAPositionReport(t *testing.T) {
 				PositionReport: PositionReport{
 					Type: 3, Repeat: 0, MMSI: 601041200, Speed: 8.1,
 					Accuracy: false, Lon: 31.130165, Lat: -29.784113333333334, Course: 243.4,
-					Heading: 230, Second: 16, RAIM: false, Radio: 135009},
-				Status: 15, Turn: -127, Maneuver: 0},
+					Heading: 230, Second: 16},
+				RAIM: false, Radio: 135009, Status: 15, Turn: -127, Maneuver: 0},
 		},
 		{
 			"13P:v?h009Ogbr4NkiITkU>L089D",
@@ -45,8 +45,8 @@ func TestDecodeClassAPositionReport(t *testing.T) {
 				PositionReport: PositionReport{
 					Type: 1, Repeat: 0, MMSI: 235060799, Speed: 0.9,
 					Accuracy: false, Lon: -3.56725, Lat: 53.84251666666667, Course: 123,
-					Heading: 167, Second: 14, RAIM: false, Radio: 33364},
-				Status: 0, Turn: 0, Maneuver: 0},
+					Heading: 167, Second: 14},
+				RAIM: false, Radio: 33364, Status: 0, Turn: 0, Maneuver: 0},
 		},
 		{
 			"13n@oD0PB@0IRqvQj@W;EppH088t19uvPT",
@@ -54,8 +54,8 @@ func TestDecodeClassAPositionReport(t *testing.T) {
 				PositionReport: PositionReport{
 					Type: 1, Repeat: 0, MMSI: 258226000, Speed: 14.4,
 					Accuracy: false, Lon: 5.580478333333334, Lat: 59.0441, Course: 290.3,
-					Heading: 284, Second: 12, RAIM: false, Radio: 33340},
-				Status: 0, Turn: -127, Maneuver: 0},
+					Heading: 284, Second: 12},
+				RAIM: false, Radio: 33340, Status: 0, Turn: -127, Maneuver: 0},
 		},
 	}
 	for _, c := range cases {
@@ -68,6 +68,54 @@ func TestDecodeClassAPositionReport(t *testing.T) {
 	}
 }
 
+func TestEncodeClassAPositionReport(t *testing.T) {
+	payloads := []string{"38u<a<?PAA2>P:WfuAO9PW<P0PuQ", "13P:v?h009Ogbr4NkiITkU>L089D"}
+	for _, payload := range payloads {
+		m, _ := DecodeClassAPositionReport(payload)
+		got, err := EncodeClassAPositionReport(m)
+		if err != nil || got != (Message{Type: m.Type, Payload: payload, Padding: 0}) {
+			fmt.Println("Got : ", got, err)
+			fmt.Println("Want: ", payload)
+			t.Errorf("EncodeClassAPositionReport(m ClassAPositionReport)")
+		}
+	}
+
+	// Round trip of values that need rounding and the special values of turn and speed
+	cases := []ClassAPositionReport{
+		{PositionReport: PositionReport{Type: 2, Repeat: 3, MMSI: 999999999, Speed: 102.2,
+			Accuracy: true, Lon: -179.99999833333334, Lat: 89.99999833333334, Course: 359.9,
+			Heading: 359, Second: 59}, RAIM: true, Radio: 524287, Status: 8, Turn: 708.66, Maneuver: 2},
+		{PositionReport: PositionReport{Type: 1, MMSI: 237000000, Speed: 1023, Lon: 181, Lat: 91,
+			Course: 360, Heading: 511, Second: 60}, Status: 15, Turn: -128},
+		{PositionReport: PositionReport{Type: 3, MMSI: 1, Speed: 12.3, Lon: 23.6451, Lat: 37.94},
+			Turn: -2.9},
+	}
+	for _, c := range cases {
+		e, err := EncodeClassAPositionReport(c)
+		got, _ := DecodeClassAPositionReport(e.Payload)
+		want := c
+		if c.Speed == 102.2 {
+			want.Speed = 1022 // 102.2 knots or higher is reported as 1022
+		}
+		if c.Turn == 708.66 {
+			want.Turn = got.Turn // only the precision of the message
+		}
+		if c.Turn == -2.9 {
+			raw := float32(-8)
+			want.Turn = -(raw / 4.733) * (raw / 4.733)
+		}
+		if err != nil || got != want {
+			fmt.Println("Got : ", got, err)
+			fmt.Println("Want: ", want)
+			t.Errorf("EncodeClassAPositionReport(m ClassAPositionReport)")
+		}
+	}
+
+	if _, err := EncodeClassAPositionReport(ClassAPositionReport{}); err == nil {
+		t.Errorf("EncodeClassAPositionReport(m ClassAPositionReport) accepted type 0")
+	}
+}
+
 func BenchmarkDecodeClassAPositionReport(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		DecodeClassAPositionReport("38u<a<?PAA2>P:WfuAO9PW<P0PuQ")
@@ -85,8 +133,8 @@ func TestDecodeClassBPositionReport(t *testing.T) {
 				PositionReport: PositionReport{
 					Type: 18, Repeat: 0, MMSI: 266119000, Speed: 0,
 					Accuracy: false, Lon: 18.085243333333334, Lat: 59.32718333333333, Course: 0,
-					Heading: 511, Second: 34, RAIM: true, Radio: 917510},
-				CSUnit: true, Display: false, DSC: true, Band: true, Msg22: true, Assigned: false},
+					Heading: 511, Second: 34},
+				RAIM: true, Radio: 917510, CSUnit: true, Display: false, DSC: true, Band: true, Msg22: true, Assigned: false},
 		},
 		{
 			"B3uIwBP008=QHv8Cerc;wwjUWP06",
@@ -94,8 +142,8 @@ func TestDecodeClassBPositionReport(t *testing.T) {
 				PositionReport: PositionReport{
 					Type: 18, Repeat: 0, MMSI: 265715530, Speed: 0,
 					Accuracy: true, Lon: 11.81546, Lat: 58.07772333333333, Course: 326.3,
-					Heading: 511, Second: 37, RAIM: true, Radio: 917510},
-				CSUnit: true, Display: false, DSC: true, Band: true, Msg22: false, Assigned: false},
+					Heading: 511, Second: 37},
+				RAIM: true, Radio: 917510, CSUnit: true, Display: false, DSC: true, Band: true, Msg22: false, Assigned: false},
 		},
 	}
 	for _, c := range cases {
@@ -108,8 +156,40 @@ func TestDecodeClassBPositionReport(t *testing.T) {
 	}
 }
 
+func TestEncodeClassBPositionReport(t *testing.T) {
+	payloads := []string{"B3ujWF0000DdVU8O:1H03wi5oP06", "B3uIwBP008=QHv8Cerc;wwjUWP06"}
+	for _, payload := range payloads {
+		m, _ := DecodeClassBPositionReport(payload)
+		got, err := EncodeClassBPositionReport(m)
+		if err != nil || got != (Message{Type: 18, Payload: payload, Padding: 0}) {
+			fmt.Println("Got : ", got, err)
+			fmt.Println("Want: ", payload)
+			t.Errorf("EncodeClassBPositionReport(m ClassBPositionReport)")
+		}
+	}
+
+	want := ClassBPositionReport{
+		PositionReport: PositionReport{Type: 18, Repeat: 1, MMSI: 244123456, Speed: 5.4,
+			Accuracy: true, Lon: 4.4, Lat: 51.9, Course: 87.5, Heading: 88, Second: 7},
+		RAIM: false, Radio: 393222, CSUnit: true, Display: true, DSC: false, Band: true, Msg22: false, Assigned: true}
+	e, err := EncodeClassBPositionReport(want)
+	got, _ := DecodeClassBPositionReport(e.Payload)
+	if err != nil || got != want {
+		fmt.Println("Got : ", got, err)
+		fmt.Println("Want: ", want)
+		t.Errorf("EncodeClassBPositionReport(m ClassBPositionReport)")
+	}
+}
+
 func BenchmarkDecodeClassBPositionReport(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		DecodeClassBPositionReport("B3ujWF0000DdVU8O:1H03wi5oP06")
 	}
 }
+
+func BenchmarkEncodeClassAPositionReport(b *testing.B) {
+	m, _ := DecodeClassAPositionReport("38u<a<?PAA2>P:WfuAO9PW<P0PuQ")
+	for i := 0; i < b.N; i++ {
+		EncodeClassAPositionReport(m)
+	}
+}
@@ -20,6 +20,7 @@ package aislib
 import (
 	"fmt"
 	"strconv"
+	"time"
 )
 
 // PrintBaseStationReport returns a formatted string of a BaseStationReport. Mainly to help
@@ -253,12 +254,60 @@ func type5size2String(min, max, size int) string {
 
 // PrintBinaryBroadcast returns a string with some data for a Binary Broadcast message
 func (m BinaryBroadcast) String() string {
+	_, bits := m.ApplicationData()
 
 	message :=
 		fmt.Sprintf("=== Binary Broadcast ===\n") +
 			fmt.Sprintf(" Repeat       : %d\n", m.Repeat) +
 			fmt.Sprintf(" MMSI         : %09d [%s]\n", m.MMSI, DecodeMMSI(m.MMSI)) +
-			fmt.Sprintf(" DAC-FID      : %d-%d (%s)\n", m.DAC, m.FID, BinaryBroadcastType[int(m.DAC)][int(m.FID)])
+			fmt.Sprintf(" DAC-FID      : %d-%d (%s)\n", m.DAC, m.FID, BinaryBroadcastType[int(m.DAC)][int(m.FID)]) +
+			fmt.Sprintf(" Data         : %d bits\n", bits)
+
+	return message
+}
+
+func (m AddressedBinary) String() string {
+	_, bits := m.ApplicationData()
+
+	message :=
+		fmt.Sprintf("=== Addressed Binary ===\n") +
+			fmt.Sprintf(" Repeat       : %d\n", m.Repeat) +
+			fmt.Sprintf(" MMSI         : %09d [%s]\n", m.MMSI, DecodeMMSI(m.MMSI)) +
+			fmt.Sprintf(" Destination  : %09d [%s]\n", m.DestMMSI, DecodeMMSI(m.DestMMSI)) +
+			fmt.Sprintf(" DAC-FID      : %d-%d (%s)\n", m.DAC, m.FID, BinaryAddressedType[int(m.DAC)][int(m.FID)]) +
+			fmt.Sprintf(" Data         : %d bits\n", bits)
+
+	return message
+}
+
+// String returns a formatted string with what is known about a vessel.
+func (v Vessel) String() string {
+	name := v.VesselName
+	if name == "" {
+		name = "unknown"
+	}
+
+	position := "not available"
+	if !v.PositionTime.IsZero() {
+		position = CoordinatesDeg2Human(v.Lon, v.Lat) + " (" + v.PositionTime.Format(time.RFC3339) + ")"
+	}
+
+	speed := "not available"
+	if v.Speed < 1022 {
+		speed = strconv.FormatFloat(float64(v.Speed), 'f', 1, 32) + " knots"
+	}
+
+	message :=
+		fmt.Sprintf("=== Vessel ===\n") +
+			fmt.Sprintf(" MMSI         : %09d [%s]\n", v.MMSI, DecodeMMSI(v.MMSI)) +
+			fmt.Sprintf(" Vessel Name  : %s\n", name) +
+			fmt.Sprintf(" Call Sign    : %s\n", v.Callsign) +
+			fmt.Sprintf(" IMO number   : %d\n", v.IMO) +
+			fmt.Sprintf(" Ship Type    : %s\n", ShipType[int(v.ShipType)]) +
+			fmt.Sprintf(" Coordinates  : %s\n", position) +
+			fmt.Sprintf(" Speed (SOG)  : %s\n", speed) +
+			fmt.Sprintf(" Destination  : %s\n", v.Destination) +
+			fmt.Sprintf(" Last Seen    : %s\n", v.LastSeen.Format(time.RFC3339))
 
 	return message
 }
@@ -0,0 +1,345 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Vessel is what a Registry knows about a station: its latest position report and static
+// data, merged from all the messages it sent. Fields that were never reported have their zero
+// value.
+type Vessel struct {
+	MMSI uint32
+
+	// Latest position, from messages of type 1, 2, 3, 18, 19 and 27
+	Lon          float64
+	Lat          float64
+	Speed        float32 // Knots, 1023 if not available
+	Course       float32 // Degrees, 360 if not available
+	Heading      uint16  // Degrees, 511 if not available
+	Status       uint8   // Navigation status, only reported by class A stations (types 1-3, 27)
+	PositionType uint8   // Type of the message that reported the position
+	PositionTime time.Time
+
+	// Static and voyage data, from messages of type 5, 19 and 24. The name (24 part A) and the
+	// rest (24 part B) are timed separately, since class B stations send them apart.
+	IMO         uint32
+	Callsign    string
+	VesselName  string
+	ShipType    uint8
+	ToBow       uint16 // Dimension to bow
+	ToStern     uint16 // Dimension to stern
+	ToPort      uint8  // Dimension to port
+	ToStarboard uint8  // Dimension to starboard
+	Destination string
+	Draught     uint8     // Meters/10
+	ETA         time.Time // Not reliable
+	NameTime    time.Time // Time of VesselName
+	StaticTime  time.Time // Time of the other static and voyage data
+
+	LastSeen time.Time
+	Messages int64 // Messages received from the vessel
+}
+
+// A Registry merges the messages of vessels into one record per MMSI, so the latest position
+// and the static data of a vessel are available together. Messages are timed by their
+// Timestamp, or the time they are added if it isn't set; an older position doesn't replace a
+// newer one.
+//
+// It is safe for concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	vessels   map[uint32]*Vessel
+	imos      map[uint32]uint32 // IMO number to MMSI
+	callsigns map[string]uint32
+	names     map[string]map[uint32]bool // Names aren't unique
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		vessels:   make(map[uint32]*Vessel),
+		imos:      make(map[uint32]uint32),
+		callsigns: make(map[string]uint32),
+		names:     make(map[string]map[uint32]bool),
+	}
+}
+
+// Update adds a message to the record of its vessel. It reports if the message was used;
+// messages of other types than the ones of Vessel and messages that don't decode aren't.
+func (g *Registry) Update(m Message) bool {
+	if len(m.Payload) == 0 {
+		return false
+	}
+	now := m.Timestamp
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	var pos *Vessel                             // The position fields of a position report
+	var static func(v *Vessel, name, rest bool) // Sets the name and/or the rest of the static data
+	var name, rest bool                         // The parts of the static data the message carries
+	switch m.Type {
+	case 1, 2, 3:
+		r, err := DecodeClassAPositionReport(m.Payload)
+		if err != nil {
+			return false
+		}
+		pos = &Vessel{MMSI: r.MMSI, Lon: r.Lon, Lat: r.Lat, Speed: r.Speed, Course: r.Course,
+			Heading: r.Heading, Status: r.Status}
+	case 18:
+		r, err := DecodeClassBPositionReport(m.Payload)
+		if err != nil {
+			return false
+		}
+		pos = &Vessel{MMSI: r.MMSI, Lon: r.Lon, Lat: r.Lat, Speed: r.Speed, Course: r.Course,
+			Heading: r.Heading}
+	case 19:
+		r, err := DecodeExtendedClassBPositionReport(m.Payload)
+		if err != nil {
+			return false
+		}
+		pos = &Vessel{MMSI: r.MMSI, Lon: r.Lon, Lat: r.Lat, Speed: r.Speed, Course: r.Course,
+			Heading: r.Heading}
+		name, rest = true, true
+		static = func(v *Vessel, name, rest bool) {
+			if name {
+				v.VesselName = r.VesselName
+			}
+			if rest {
+				v.ShipType = r.ShipType
+				v.ToBow, v.ToStern, v.ToPort, v.ToStarboard = r.ToBow, r.ToStern, r.ToPort, r.ToStarboard
+			}
+		}
+	case 27:
+		pos = decodeLongRangePosition(m.Payload)
+	case 5:
+		r, err := DecodeStaticVoyageData(m.Payload)
+		if err != nil {
+			return false
+		}
+		pos = &Vessel{MMSI: r.MMSI}
+		name, rest = true, true
+		static = func(v *Vessel, name, rest bool) {
+			if name {
+				v.VesselName = r.VesselName
+			}
+			if rest {
+				v.IMO, v.Callsign, v.ShipType = r.IMO, r.Callsign, r.ShipType
+				v.ToBow, v.ToStern, v.ToPort, v.ToStarboard = r.ToBow, r.ToStern, r.ToPort, r.ToStarboard
+				v.Destination, v.Draught, v.ETA = r.Destination, r.Draught, r.ETA
+			}
+		}
+	case 24:
+		r, err := DecodeStaticDataReport(m.Payload)
+		if err != nil {
+			return false
+		}
+		pos = &Vessel{MMSI: r.MMSI}
+		name, rest = r.PartNo == 0, r.PartNo != 0
+		static = func(v *Vessel, name, rest bool) {
+			if name {
+				v.VesselName = r.VesselName
+				return
+			}
+			v.ShipType, v.Callsign = r.ShipType, r.CallSign
+			if r.MothershipMMSI == 0 {
+				v.ToBow, v.ToStern, v.ToPort, v.ToStarboard = r.ToBow, r.ToStern, r.ToPort, r.ToStarboard
+			}
+		}
+	}
+	if pos == nil || pos.MMSI == 0 {
+		return false
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	v, ok := g.vessels[pos.MMSI]
+	if !ok {
+		v = &Vessel{MMSI: pos.MMSI}
+		g.vessels[pos.MMSI] = v
+	}
+	v.Messages++
+	if now.After(v.LastSeen) {
+		v.LastSeen = now
+	}
+
+	_, _, located := MessagePosition(m.Payload)
+	if located && !now.Before(v.PositionTime) {
+		v.Lon, v.Lat, v.Speed, v.Course, v.Heading = pos.Lon, pos.Lat, pos.Speed, pos.Course, pos.Heading
+		if m.Type <= 3 || m.Type == 27 {
+			v.Status = pos.Status
+		}
+		v.PositionType, v.PositionTime = m.Type, now
+	}
+	name = name && !now.Before(v.NameTime)
+	rest = rest && !now.Before(v.StaticTime)
+	if name || rest {
+		g.unindex(v)
+		static(v, name, rest)
+		if name {
+			v.NameTime = now
+		}
+		if rest {
+			v.StaticTime = now
+		}
+		g.index(v)
+	}
+	return true
+}
+
+// decodeLongRangePosition decodes the position of a long range report (type 27).
+func decodeLongRangePosition(payload string) *Vessel {
+	r := NewBitReader(payload)
+	if r.Len() < 95 {
+		return nil
+	}
+	v := &Vessel{MMSI: uint32(r.Uint(8, 37)), Status: uint8(r.Uint(40, 43)),
+		Speed: float32(r.Uint(79, 84)), Course: float32(r.Uint(85, 93)), Heading: 511}
+	v.Lon, v.Lat, _ = MessagePosition(payload)
+	if v.Speed == 63 {
+		v.Speed = 1023
+	}
+	if v.Course == 511 {
+		v.Course = 360
+	}
+	return v
+}
+
+// normalizeName prepares a name or callsign for lookups.
+func normalizeName(name string) string {
+	return strings.ToUpper(strings.TrimSpace(name))
+}
+
+// index adds a vessel to the lookup indexes. It is called with mu held.
+func (g *Registry) index(v *Vessel) {
+	if v.IMO != 0 {
+		g.imos[v.IMO] = v.MMSI
+	}
+	if cs := normalizeName(v.Callsign); cs != "" {
+		g.callsigns[cs] = v.MMSI
+	}
+	if name := normalizeName(v.VesselName); name != "" {
+		if g.names[name] == nil {
+			g.names[name] = make(map[uint32]bool)
+		}
+		g.names[name][v.MMSI] = true
+	}
+}
+
+// unindex removes a vessel from the lookup indexes. It is called with mu held.
+func (g *Registry) unindex(v *Vessel) {
+	if g.imos[v.IMO] == v.MMSI {
+		delete(g.imos, v.IMO)
+	}
+	if cs := normalizeName(v.Callsign); g.callsigns[cs] == v.MMSI {
+		delete(g.callsigns, cs)
+	}
+	if name := normalizeName(v.VesselName); g.names[name] != nil {
+		delete(g.names[name], v.MMSI)
+		if len(g.names[name]) == 0 {
+			delete(g.names, name)
+		}
+	}
+}
+
+// Vessel returns the record of a vessel by its MMSI.
+func (g *Registry) Vessel(mmsi uint32) (Vessel, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if v, ok := g.vessels[mmsi]; ok {
+		return *v, true
+	}
+	return Vessel{}, false
+}
+
+// ByIMO returns the record of the vessel that last reported an IMO number.
+func (g *Registry) ByIMO(imo uint32) (Vessel, bool) {
+	g.mu.RLock()
+	mmsi, ok := g.imos[imo]
+	g.mu.RUnlock()
+	if !ok {
+		return Vessel{}, false
+	}
+	return g.Vessel(mmsi)
+}
+
+// ByCallsign returns the record of the vessel that last reported a callsign. Case and
+// surrounding spaces don't matter.
+func (g *Registry) ByCallsign(callsign string) (Vessel, bool) {
+	g.mu.RLock()
+	mmsi, ok := g.callsigns[normalizeName(callsign)]
+	g.mu.RUnlock()
+	if !ok {
+		return Vessel{}, false
+	}
+	return g.Vessel(mmsi)
+}
+
+// ByName returns the records of the vessels with a name, by MMSI. Case and surrounding spaces
+// don't matter.
+func (g *Registry) ByName(name string) []Vessel {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	var vessels []Vessel
+	for mmsi := range g.names[normalizeName(name)] {
+		vessels = append(vessels, *g.vessels[mmsi])
+	}
+	sort.Slice(vessels, func(i, j int) bool { return vessels[i].MMSI < vessels[j].MMSI })
+	return vessels
+}
+
+// Vessels returns the records of all vessels seen since a time (zero for all), by MMSI.
+func (g *Registry) Vessels(since time.Time) []Vessel {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	vessels := make([]Vessel, 0, len(g.vessels))
+	for _, v := range g.vessels {
+		if !v.LastSeen.Before(since) {
+			vessels = append(vessels, *v)
+		}
+	}
+	sort.Slice(vessels, func(i, j int) bool { return vessels[i].MMSI < vessels[j].MMSI })
+	return vessels
+}
+
+// Len returns the number of vessels in the registry.
+func (g *Registry) Len() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.vessels)
+}
+
+// Expire removes the vessels not seen since a time and returns how many were removed.
+func (g *Registry) Expire(before time.Time) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	n := 0
+	for mmsi, v := range g.vessels {
+		if v.LastSeen.Before(before) {
+			g.unindex(v)
+			delete(g.vessels, mmsi)
+			n++
+		}
+	}
+	return n
+}
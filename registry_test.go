@@ -0,0 +1,199 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testExtendedClassB builds a type 19 payload, there is no encoder for it.
+func testExtendedClassB(mmsi uint32, lon, lat float64, name string) string {
+	w := NewBitWriter(312)
+	w.Uint(0, 5, 19)
+	w.Uint(8, 37, uint64(mmsi))
+	w.Uint(46, 55, 52)
+	cbnPutCoordinates(57, w, lon, lat)
+	w.Uint(112, 123, 900)
+	w.Uint(124, 132, 91)
+	w.String(143, 262, name)
+	w.Uint(263, 270, 37)
+	w.Uint(271, 279, 10)
+	payload, _ := w.Payload()
+	return payload
+}
+
+// testLongRange builds a type 27 payload.
+func testLongRange(mmsi uint32, lon, lat float64, speed, course uint64) string {
+	w := NewBitWriter(96)
+	w.Uint(0, 5, 27)
+	w.Uint(8, 37, uint64(mmsi))
+	w.Uint(40, 43, 5)
+	w.Int(44, 61, int64(lon*600))
+	w.Int(62, 78, int64(lat*600))
+	w.Uint(79, 84, speed)
+	w.Uint(85, 93, course)
+	payload, _ := w.Payload()
+	return payload
+}
+
+func TestRegistry(t *testing.T) {
+	t0 := time.Unix(1425772826, 0).UTC()
+	voyage, _ := EncodeStaticVoyageData(StaticVoyageData{MMSI: 601041200, IMO: 9074729, Callsign: "ZSAB",
+		VesselName: "OCEAN STAR", ShipType: 70, ToBow: 100, ToStern: 20, ToPort: 8, ToStarboard: 8,
+		Destination: "DURBAN", Draught: 85})
+	renamed, _ := EncodeStaticVoyageData(StaticVoyageData{MMSI: 601041200, IMO: 9074729, Callsign: "ZSXY",
+		VesselName: "OCEAN STAR", ShipType: 70})
+	older, _ := EncodeClassAPositionReport(ClassAPositionReport{
+		PositionReport: PositionReport{Type: 1, MMSI: 601041200, Lon: 30, Lat: -30}})
+	partA, _ := EncodeStaticDataReport(StaticDataReport{MMSI: 266119000, PartNo: 0, VesselName: "SEA BREEZE"})
+	partB, _ := EncodeStaticDataReport(StaticDataReport{MMSI: 266119000, PartNo: 1, ShipType: 36,
+		CallSign: "SBRZ", ToBow: 8, ToStern: 4, ToPort: 2, ToStarboard: 2})
+
+	g := NewRegistry()
+	updates := []struct {
+		m    Message
+		want bool
+	}{
+		{Message{Type: 3, Payload: "38u<a<?PAA2>P:WfuAO9PW<P0PuQ", Timestamp: t0}, true},
+		{Message{Type: 5, Payload: voyage.Payload, Timestamp: t0.Add(time.Second)}, true},
+		{Message{Type: 1, Payload: older.Payload, Timestamp: t0.Add(-time.Minute)}, true},
+		{Message{Type: 18, Payload: "B3ujWF0000DdVU8O:1H03wi5oP06", Timestamp: t0.Add(2 * time.Second)}, true},
+		{Message{Type: 24, Payload: partA.Payload, Timestamp: t0.Add(3 * time.Second)}, true},
+		{Message{Type: 24, Payload: partB.Payload, Timestamp: t0.Add(4 * time.Second)}, true},
+		{Message{Type: 19, Payload: testExtendedClassB(244000001, 4.5, 52, "OCEAN STAR"), Timestamp: t0.Add(5 * time.Second)}, true},
+		{Message{Type: 27, Payload: testLongRange(538000001, 60.5, 12.25, 63, 511), Timestamp: t0.Add(6 * time.Second)}, true},
+		{Message{Type: 4, Payload: "402R3KiutR0Qk156V4QQTOA00<0;", Timestamp: t0}, false},
+		{Message{Type: 1, Payload: "", Timestamp: t0}, false},
+	}
+	for _, u := range updates {
+		if got := g.Update(u.m); got != u.want {
+			t.Errorf("Registry.Update(%v) = %t, want %t", u.m, got, u.want)
+		}
+	}
+
+	want := Vessel{MMSI: 601041200, Lon: 31.130165, Lat: -29.784113333333334, Speed: 8.1, Course: 243.4,
+		Heading: 230, Status: 15, PositionType: 3, PositionTime: t0,
+		IMO: 9074729, Callsign: "ZSAB", VesselName: "OCEAN STAR", ShipType: 70, ToBow: 100, ToStern: 20,
+		ToPort: 8, ToStarboard: 8, Destination: "DURBAN", Draught: 85, NameTime: t0.Add(time.Second),
+		StaticTime: t0.Add(time.Second),
+		LastSeen:   t0.Add(time.Second), Messages: 3}
+	got, ok := g.Vessel(601041200)
+	want.ETA = got.ETA // Not available, decodes as a date of year 0
+	if !ok || got != want {
+		fmt.Println("Got : ", got)
+		fmt.Println("Want: ", want)
+		t.Errorf("Registry.Vessel(mmsi uint32)")
+	}
+	if got, ok := g.ByIMO(9074729); !ok || got.MMSI != 601041200 {
+		t.Errorf("Registry.ByIMO(imo uint32) = %d, %t", got.MMSI, ok)
+	}
+
+	classB, _ := g.ByCallsign(" sbrz")
+	if classB.MMSI != 266119000 || classB.VesselName != "SEA BREEZE" || classB.ShipType != 36 ||
+		classB.ToBow != 8 || classB.PositionType != 18 || classB.Lat != 59.32718333333333 {
+		t.Errorf("Registry.ByCallsign(callsign string) = %v", classB)
+	}
+
+	names := g.ByName("Ocean Star")
+	if len(names) != 2 || names[0].MMSI != 244000001 || names[1].MMSI != 601041200 ||
+		names[0].ShipType != 37 || names[0].Course != 90 || names[0].Heading != 91 || names[0].Lon != 4.5 {
+		t.Errorf("Registry.ByName(name string) = %v", names)
+	}
+
+	longRange, _ := g.Vessel(538000001)
+	if longRange.Lon != 60.5 || longRange.Lat != 12.25 || longRange.Speed != 1023 || longRange.Course != 360 ||
+		longRange.Status != 5 || longRange.PositionType != 27 {
+		t.Errorf("Registry.Vessel(mmsi uint32) = %v for a long range report", longRange)
+	}
+
+	// A new callsign replaces the old one in the index
+	g.Update(Message{Type: 5, Payload: renamed.Payload, Timestamp: t0.Add(time.Minute)})
+	if _, ok := g.ByCallsign("ZSAB"); ok {
+		t.Errorf("Registry.ByCallsign(callsign string) found an old callsign")
+	}
+	if v, ok := g.ByCallsign("ZSXY"); !ok || v.MMSI != 601041200 || v.Destination != "" {
+		t.Errorf("Registry.ByCallsign(callsign string) = %v, %t", v, ok)
+	}
+
+	if n := len(g.Vessels(t0.Add(5 * time.Second))); n != 3 {
+		t.Errorf("Registry.Vessels(since time.Time) returned %d vessels, want 3", n)
+	}
+	if n := g.Expire(t0.Add(5 * time.Second)); n != 1 || g.Len() != 3 {
+		t.Errorf("Registry.Expire(before time.Time) = %d, left %d, want 1 and 3", n, g.Len())
+	}
+	if _, ok := g.ByCallsign("SBRZ"); ok || len(g.ByName("SEA BREEZE")) != 0 {
+		t.Errorf("Registry.Expire(before time.Time) left the indexes of an expired vessel")
+	}
+}
+
+func TestRegistryStaticParts(t *testing.T) {
+	t0 := time.Unix(1425772826, 0).UTC()
+	partA, _ := EncodeStaticDataReport(StaticDataReport{MMSI: 266119000, PartNo: 0, VesselName: "SEA BREEZE"})
+	partB, _ := EncodeStaticDataReport(StaticDataReport{MMSI: 266119000, PartNo: 1, ShipType: 36, CallSign: "SBRZ"})
+	oldB, _ := EncodeStaticDataReport(StaticDataReport{MMSI: 266119000, PartNo: 1, ShipType: 37, CallSign: "SBRA"})
+	voyage, _ := EncodeStaticVoyageData(StaticVoyageData{MMSI: 266119000, Callsign: "SBRV", VesselName: "NEW BREEZE",
+		ShipType: 70})
+
+	// A part B that arrives after a newer part A is still used, an older one isn't
+	g := NewRegistry()
+	g.Update(Message{Type: 24, Payload: oldB.Payload, Timestamp: t0})
+	g.Update(Message{Type: 24, Payload: partA.Payload, Timestamp: t0.Add(2 * time.Second)})
+	g.Update(Message{Type: 24, Payload: partB.Payload, Timestamp: t0.Add(time.Second)})
+	g.Update(Message{Type: 24, Payload: oldB.Payload, Timestamp: t0})
+	v, _ := g.Vessel(266119000)
+	if v.VesselName != "SEA BREEZE" || v.Callsign != "SBRZ" || v.ShipType != 36 ||
+		!v.NameTime.Equal(t0.Add(2*time.Second)) || !v.StaticTime.Equal(t0.Add(time.Second)) {
+		t.Errorf("Registry.Vessel(mmsi uint32) = %v, %v, %v", v, v.NameTime, v.StaticTime)
+	}
+
+	// A type 5 between the two parts only updates the part that is older
+	g.Update(Message{Type: 5, Payload: voyage.Payload, Timestamp: t0.Add(1500 * time.Millisecond)})
+	v, _ = g.Vessel(266119000)
+	if v.VesselName != "SEA BREEZE" || v.Callsign != "SBRV" || v.ShipType != 70 {
+		t.Errorf("Registry.Vessel(mmsi uint32) = %v after a type 5", v)
+	}
+	if _, ok := g.ByCallsign("SBRZ"); ok || len(g.ByName("NEW BREEZE")) != 0 {
+		t.Errorf("Registry indexes old static data")
+	}
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	g := NewRegistry()
+	voyage, _ := EncodeStaticVoyageData(StaticVoyageData{MMSI: 601041200, Callsign: "ZSAB", VesselName: "OCEAN STAR"})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				g.Update(Message{Type: 3, Payload: "38u<a<?PAA2>P:WfuAO9PW<P0PuQ"})
+				g.Update(Message{Type: 5, Payload: voyage.Payload})
+				g.ByCallsign("ZSAB")
+				g.ByName("OCEAN STAR")
+				g.Vessels(time.Time{})
+			}
+		}()
+	}
+	wg.Wait()
+	if v, _ := g.Vessel(601041200); v.Messages != 800 {
+		t.Errorf("Registry counted %d messages, want 800", v.Messages)
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Replayer plays a recorded capture back with its original timing, for demos and tests. The
+// lines are paced by their timestamps (tag block c: or a timestamp prefix, see LineParser) and
+// sent unchanged: to a channel (e.g the in channel of a Router, with Archive set to keep the
+// timestamps) with Run, to a UDP or TCP connection with RunWriter and to the clients of a
+// Server with RunServer.
+//
+// Lines without a timestamp take the one of the line before them, or of the first line with
+// one at the start of the capture. Timestamps should not go backwards; a line older than the
+// one before it is sent right away.
+//
+// The controls (Pause, Resume, Seek, SetSpeed) are safe to call while a replay is running.
+type Replayer struct {
+	lines []string
+	times []time.Time
+
+	mu         sync.Mutex
+	speed      float64
+	paused     bool
+	next       int       // Index of the next line to send
+	startWall  time.Time // Pacing anchor: at startWall we were at startTime of the capture
+	startTime  time.Time
+	generation int           // Changes with every control call, so Run re-plans its wait
+	wake       chan struct{} // Wakes Run up after a control call
+}
+
+// NewReplayer reads a capture from r and returns a Replayer for it, at original speed.
+func NewReplayer(r io.Reader) (*Replayer, error) {
+	p := &Replayer{speed: 1, wake: make(chan struct{}, 1)}
+	parser := NewLineParser()
+	var last time.Time
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if _, info, err := parser.Parse(line); err == nil && !info.Timestamp.IsZero() {
+			last = info.Timestamp
+		}
+		p.lines = append(p.lines, line)
+		p.times = append(p.times, last)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(p.lines) == 0 {
+		return nil, errors.New("Capture is empty.")
+	}
+	// The lines before the first timestamp go with it
+	first := 0
+	for first < len(p.times) && p.times[first].IsZero() {
+		first++
+	}
+	if first < len(p.times) {
+		for i := 0; i < first; i++ {
+			p.times[i] = p.times[first]
+		}
+	}
+	return p, nil
+}
+
+// Start returns the timestamp of the first line of the capture.
+func (p *Replayer) Start() time.Time { return p.times[0] }
+
+// End returns the timestamp of the last line of the capture.
+func (p *Replayer) End() time.Time { return p.times[len(p.times)-1] }
+
+// Position returns the timestamp of the next line to be sent, or End at the end of the capture.
+func (p *Replayer) Position() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.next >= len(p.times) {
+		return p.End()
+	}
+	return p.times[p.next]
+}
+
+// SetSpeed sets the speed of the replay: 1 is the original timing, 10 ten times faster. Zero or
+// a negative speed sends the lines as fast as they are read.
+func (p *Replayer) SetSpeed(speed float64) {
+	p.control(func() { p.speed = speed })
+}
+
+// Pause stops sending lines until Resume.
+func (p *Replayer) Pause() {
+	p.control(func() { p.paused = true })
+}
+
+// Resume continues a paused replay from where it stopped.
+func (p *Replayer) Resume() {
+	p.control(func() { p.paused = false })
+}
+
+// Seek moves the replay to the first line with a timestamp at or after t. Seeking past the
+// end finishes the replay.
+func (p *Replayer) Seek(t time.Time) {
+	p.control(func() {
+		p.next = sort.Search(len(p.times), func(i int) bool { return !p.times[i].Before(t) })
+	})
+}
+
+// control applies a change and restarts the pacing from the current position.
+func (p *Replayer) control(change func()) {
+	p.mu.Lock()
+	change()
+	p.anchor()
+	p.generation++
+	p.mu.Unlock()
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// anchor restarts the pacing from the next line. It is called with mu held.
+func (p *Replayer) anchor() {
+	p.startWall = time.Now()
+	if p.next < len(p.times) {
+		p.startTime = p.times[p.next]
+	}
+}
+
+// Run sends the lines to out, paced by their timestamps, until the capture ends (it returns
+// nil) or ctx is cancelled (it returns the context's error). Run may be called again to replay
+// from the current position, e.g after a Seek.
+func (p *Replayer) Run(ctx context.Context, out chan<- string) error {
+	return p.play(ctx, func(line string) error {
+		select {
+		case out <- line:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// RunWriter works like Run, writing every line to w with a CRLF. For UDP connections (see
+// net.Dial) every line goes in its own datagram. A write error stops the replay and is returned.
+func (p *Replayer) RunWriter(ctx context.Context, w io.Writer) error {
+	return p.play(ctx, func(line string) error {
+		_, err := io.WriteString(w, line+"\r\n")
+		return err
+	})
+}
+
+// RunServer works like Run, broadcasting the lines to the clients of s.
+func (p *Replayer) RunServer(ctx context.Context, s *Server) error {
+	return p.play(ctx, func(line string) error {
+		s.Broadcast(line)
+		return nil
+	})
+}
+
+// play paces the lines and passes them to send, until the capture ends, ctx is cancelled or
+// send fails.
+func (p *Replayer) play(ctx context.Context, send func(line string) error) error {
+	p.mu.Lock()
+	p.anchor()
+	p.mu.Unlock()
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		p.mu.Lock()
+		if p.next >= len(p.lines) {
+			p.mu.Unlock()
+			return nil
+		}
+		paused, generation := p.paused, p.generation
+		line := p.lines[p.next]
+		wait := time.Duration(0)
+		if p.speed > 0 {
+			offset := time.Duration(float64(p.times[p.next].Sub(p.startTime)) / p.speed)
+			wait = time.Until(p.startWall.Add(offset))
+		}
+		p.mu.Unlock()
+
+		if paused {
+			select {
+			case <-p.wake:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			continue
+		}
+		if wait > 0 {
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(wait)
+			select {
+			case <-timer.C:
+			case <-p.wake:
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		p.mu.Lock()
+		changed := generation != p.generation
+		if !changed {
+			p.next++
+		}
+		p.mu.Unlock()
+		if changed {
+			continue
+		}
+
+		if err := send(line); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+var replayCapture = strings.Join([]string{
+	"1425772826 !AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F",
+	"\\g:1-2-73874,s:rx2,c:1425772830*42\\!AIVDM,2,1,5,A,533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H,0*44",
+	"\\g:2-2-73874*62\\!AIVDM,2,2,5,A,51CU0E2CkP0,2*0C",
+	"",
+	"1425772836 !AIVDM,1,1,,B,B3ujWF0000DdVU8O:1H03wi5oP06,0*5C",
+	"1425776426 !AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F",
+}, "\r\n")
+
+// receiveLine waits for a line from out.
+func receiveLine(t *testing.T, out chan string, within time.Duration) string {
+	select {
+	case l := <-out:
+		return l
+	case <-time.After(within):
+		t.Fatalf("Replayer.Run() sent nothing for %v", within)
+	}
+	return ""
+}
+
+func TestReplayer(t *testing.T) {
+	p, err := NewReplayer(strings.NewReader(replayCapture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Unix(1425772826, 0)
+	if !p.Start().Equal(t0) || !p.End().Equal(t0.Add(time.Hour)) {
+		t.Errorf("Replayer spans %v to %v", p.Start(), p.End())
+	}
+
+	// At 100x the lines are 40ms, 0 and 60ms apart, then an hour passes
+	p.SetSpeed(100)
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan string)
+	done := make(chan error)
+	go func() { done <- p.Run(ctx, out) }()
+
+	start := time.Now()
+	want := []time.Duration{0, 40 * time.Millisecond, 40 * time.Millisecond, 100 * time.Millisecond}
+	lines := strings.Split(replayCapture, "\r\n")
+	for i, l := range []string{lines[0], lines[1], lines[2], lines[4]} {
+		got := receiveLine(t, out, time.Second)
+		elapsed := time.Since(start)
+		if got != l || elapsed < want[i] || elapsed > want[i]+50*time.Millisecond {
+			t.Errorf("Replayer.Run() sent %q after %v, want %q after %v", got, elapsed, l, want[i])
+		}
+	}
+
+	// Seeking while waiting sends the line at once
+	if !p.Position().Equal(t0.Add(time.Hour)) {
+		t.Errorf("Replayer.Position() = %v, want %v", p.Position(), t0.Add(time.Hour))
+	}
+	p.Seek(t0.Add(time.Minute))
+	if got := receiveLine(t, out, 100*time.Millisecond); got != lines[5] {
+		t.Errorf("Replayer.Run() sent %q after seeking, want %q", got, lines[5])
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Replayer.Run() = %v, want nil at the end", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Replayer.Run() didn't stop at the end")
+	}
+	cancel()
+}
+
+func TestReplayerPause(t *testing.T) {
+	p, err := NewReplayer(strings.NewReader(replayCapture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetSpeed(0)
+	p.Pause()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan string, 8)
+	done := make(chan error)
+	go func() { done <- p.Run(ctx, out) }()
+
+	select {
+	case l := <-out:
+		t.Errorf("Replayer.Run() sent %q while paused", l)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Seek back and forth, then play the rest as fast as possible
+	p.Seek(time.Unix(1425772836, 0).Add(time.Second))
+	p.Seek(time.Unix(1425772830, 0))
+	p.Resume()
+	lines := strings.Split(replayCapture, "\r\n")
+	for _, l := range []string{lines[1], lines[2], lines[4], lines[5]} {
+		if got := receiveLine(t, out, 100*time.Millisecond); got != l {
+			t.Errorf("Replayer.Run() sent %q, want %q", got, l)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Replayer.Run() = %v, want nil", err)
+	}
+
+	// Cancelling a replay that waits
+	p.Seek(p.Start())
+	p.SetSpeed(1)
+	go func() { done <- p.Run(ctx, out) }()
+	receiveLine(t, out, 100*time.Millisecond)
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Replayer.Run() = %v, want context.Canceled", err)
+	}
+
+	if _, err := NewReplayer(strings.NewReader("\r\n")); err == nil {
+		t.Errorf("NewReplayer(r io.Reader) accepted an empty capture")
+	}
+}
+
+func TestReplayerUntimed(t *testing.T) {
+	// The lines before the first timestamp are sent with it
+	lines := strings.Split(replayCapture, "\r\n")
+	capture := strings.Join([]string{
+		"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F",
+		"!AIVDM,1,1,,B,B3ujWF0000DdVU8O:1H03wi5oP06,0*5C",
+		lines[0],
+		"1425772828 !AIVDM,1,1,,B,B3ujWF0000DdVU8O:1H03wi5oP06,0*5C",
+	}, "\n")
+	p, err := NewReplayer(strings.NewReader(capture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Unix(1425772826, 0)
+	if !p.Start().Equal(t0) || !p.End().Equal(t0.Add(2*time.Second)) {
+		t.Errorf("Replayer spans %v to %v, want %v to %v", p.Start(), p.End(), t0, t0.Add(2*time.Second))
+	}
+
+	p.SetSpeed(10)
+	out := make(chan string)
+	done := make(chan error)
+	go func() { done <- p.Run(context.Background(), out) }()
+	start := time.Now()
+	want := []time.Duration{0, 0, 0, 200 * time.Millisecond}
+	for i, l := range strings.Split(capture, "\n") {
+		got := receiveLine(t, out, time.Second)
+		elapsed := time.Since(start)
+		if got != l || elapsed < want[i] || elapsed > want[i]+50*time.Millisecond {
+			t.Errorf("Replayer.Run() sent %q after %v, want %q after %v", got, elapsed, l, want[i])
+		}
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Replayer.Run() = %v, want nil", err)
+	}
+}
+
+func TestReplayerOutputs(t *testing.T) {
+	p, err := NewReplayer(strings.NewReader(replayCapture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetSpeed(0)
+	var lines []string
+	for _, l := range strings.Split(replayCapture, "\r\n") {
+		if l != "" {
+			lines = append(lines, l)
+		}
+	}
+
+	// UDP, a datagram per line
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	conn, err := net.Dial("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := p.RunWriter(context.Background(), conn); err != nil {
+		t.Errorf("Replayer.RunWriter() = %v, want nil", err)
+	}
+	pc.SetReadDeadline(time.Now().Add(time.Second))
+	buf := make([]byte, 1500)
+	for _, l := range lines {
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(buf[:n]); got != l+"\r\n" {
+			t.Errorf("Replayer.RunWriter() sent %q, want %q", got, l+"\r\n")
+		}
+	}
+
+	// TCP, through a server
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(RouterOptions{Archive: true})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Serve(ctx, ln)
+	client, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	waitFor(t, "client", func() bool { return s.Clients() == 1 })
+
+	p.Seek(p.Start())
+	if err := p.RunServer(ctx, s); err != nil {
+		t.Errorf("Replayer.RunServer() = %v, want nil", err)
+	}
+	client.SetReadDeadline(time.Now().Add(time.Second))
+	r := bufio.NewReader(client)
+	for _, l := range lines {
+		got, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l = l[strings.IndexByte(l, '!'):]; got != l+"\r\n" { // Without the decoration
+			t.Errorf("Replayer.RunServer() sent %q, want %q", got, l+"\r\n")
+		}
+	}
+}
@@ -20,6 +20,7 @@ package aislib
 import (
 	"strconv"
 	"strings"
+	"time"
 )
 
 // A Message stores the important properties of a AIS message, including only information useful
@@ -30,6 +31,11 @@ type Message struct {
 	Type    uint8
 	Payload string
 	Padding uint8
+
+	// Timestamp and Source are set when the sentences come from an archive or a receiver
+	// that records them (see LineParser). They are the zero value otherwise.
+	Timestamp time.Time
+	Source    string
 }
 
 // FailedSentence includes an AIS sentence that failed to process (e.g wrong checksum) and the reason
@@ -39,6 +45,12 @@ type FailedSentence struct {
 	Issue    string
 }
 
+// aisIdentifiers are the talker IDs and the start of the formatter (VDM/VDO) of AIS sentences.
+var aisIdentifiers = map[string]bool{
+	"ABVD": true, "ADVD": true, "AIVD": true, "ANVD": true, "ARVD": true,
+	"ASVD": true, "ATVD": true, "AXVD": true, "BSVD": true, "SAVD": true,
+}
+
 // Router accepts AIS radio sentences and process them. It checks their checksum,
 // and AIS identifiers. If they are valid it tries to assemble the payload if it spans
 // on multiple sentences. Upon success it returns the AIS Message at the out channel.
@@ -46,76 +58,154 @@ type FailedSentence struct {
 // If the in channel is closed, then it sends a message with type 255 at the out channel.
 // Your function can check for this message to know when it is safe to exit the program.
 func Router(in chan string, out chan Message, failed chan FailedSentence) {
-	count, ccount, padding := 0, 0, 0
-	size, id := "0", "0"
-	payload := ""
-	var cache [5]string
+	RouterWithOptions(in, out, failed, RouterOptions{})
+}
+
+// RouterOptions changes how RouterWithOptions processes sentences. The zero value gives the
+// behaviour of Router.
+type RouterOptions struct {
+	Checksum ChecksumMode // How strictly checksums are checked, see ChecksumMode
+
+	// If NMEA is set, sentences that aren't AIVDM/AIVDO are parsed with ParseNMEASentence and sent
+	// there, so own ship data (position, time, heading) arrive through the same pipeline.
+	// Sentences that fail to parse or aren't supported go to the failed channel.
+	NMEA chan NMEASentence
+
+	// If Archive is set, lines go through a LineParser before processing, so sentences may be
+	// decorated with timestamps and sources. Messages carry those of their first sentence.
+	Archive bool
+}
+
+// RouterWithOptions works like Router, with the behaviour adjusted by opts, so it can handle
+// receivers that emit slightly nonstandard sentences.
+func RouterWithOptions(in chan string, out chan Message, failed chan FailedSentence, opts RouterOptions) {
+	r := newRouter(opts, func(m Message) { out <- m }, func(f FailedSentence) { failed <- f })
+	for sentence := range in {
+		r.process(sentence)
+	}
+	out <- Message{Type: 255}
+}
+
+// router holds the state of the routing logic between sentences, so it can run over channels
+// (RouterWithOptions) or be called directly (Decoder).
+type router struct {
+	opts   RouterOptions
+	out    func(Message)
+	failed func(FailedSentence)
+
+	count    int
+	size, id string
+	payload  string
+	cache    [9]string // NMEA183 messages span at most 9 sentences
+	single   [1]string // The sentence of a single-sentence message
+	lines    []string  // The clean sentences of the message being sent to out, valid only during the call
+	parser   *LineParser
+	info     LineInfo // Timestamp and source of the current sentence
+	defaults LineInfo // For sentences that don't carry them, e.g the sender and time of a datagram
+	first    LineInfo // Timestamp and source of the first sentence of a multi-sentence message
+}
+
+func newRouter(opts RouterOptions, out func(Message), failed func(FailedSentence)) *router {
+	r := &router{opts: opts, out: out, failed: failed, size: "0", id: "0"}
+	if opts.Archive {
+		r.parser = NewLineParser()
+	}
+	return r
+}
+
+// process processes a sentence, calling out for every message completed and failed for every
+// sentence that can't be used.
+func (r *router) process(sentence string) {
+	var valid bool
 	var err error
-	aisIdentifiers := map[string]bool{
-		"ABVD": true, "ADVD": true, "AIVD": true, "ANVD": true, "ARVD": true,
-		"ASVD": true, "ATVD": true, "AXVD": true, "BSVD": true, "SAVD": true,
+	if len(sentence) == 0 { // Do not process empty lines
+		r.failed(FailedSentence{sentence, "Empty line"})
+		return
 	}
-	for sentence := range in {
-		if len(sentence) == 0 { // Do not process empty lines
-			failed <- FailedSentence{sentence, "Empty line"}
-			continue
+	r.info = LineInfo{}
+	if r.parser != nil {
+		if sentence, r.info, err = r.parser.Parse(sentence); err != nil {
+			r.failed(FailedSentence{sentence, err.Error()})
+			return
 		}
-		tokens := strings.Split(sentence, ",") // I think this takes the major portion of time for this function (after benchmarking)
-
-		if !Nmea183ChecksumCheck(sentence) { // Checksum check
-			failed <- FailedSentence{sentence, "Checksum failed"}
-			continue
+		if sentence == "" { // The line carries information for the next sentence
+			return
 		}
+	}
+	r.info = mergeLineInfo(r.info, r.defaults)
+	if sentence, valid = Nmea183ChecksumCheckMode(sentence, r.opts.Checksum); !valid { // Checksum check
+		r.failed(FailedSentence{sentence, "Checksum failed"})
+		return
+	}
 
-		if !aisIdentifiers[tokens[0][1:5]] { // Check for valid AIS identifier
-			failed <- FailedSentence{sentence, "Sentence isn't AIVDM/AIVDO"}
-			continue
+	if r.opts.NMEA != nil && (len(sentence) < 5 || !aisIdentifiers[sentence[1:5]]) {
+		if parsed, err := ParseNMEASentence(sentence); err == nil {
+			r.opts.NMEA <- parsed
+		} else {
+			r.failed(FailedSentence{sentence, err.Error()})
 		}
+		return
+	}
 
-		if tokens[1] == "1" { // One sentence message, process it immediately
-			padding, _ = strconv.Atoi(tokens[6][:1])
-			out <- Message{MessageType(tokens[5]), tokens[5], uint8(padding)}
-			if count > 1 { // Invalidate cache
-				for i := 0; i < count; i++ {
-					failed <- FailedSentence{cache[i], "Incomplete/out of order span sentence"}
-				}
-				count = 0
-				payload = ""
-			}
-		} else { // Message spans across sentences.
-			ccount, err = strconv.Atoi(tokens[2])
-			if err != nil {
-				failed <- FailedSentence{sentence, "HERE " + tokens[2]}
-				continue
-			}
-			if ccount != count+1 || // If there are sentences with wrong seq.number in cache send them as failed
-				tokens[3] != id && count != 0 || // If there are sentences with different sequence id in cache , send old parts as failed
-				tokens[1] != size && count != 0 { // If there messages with wrong size in cache, send them as failed
-				for i := 0; i < count; i++ {
-					failed <- FailedSentence{cache[i], "Incomplete/out of order span sentence"}
-				}
-				if ccount != 1 { // The current one is invalid too
-					failed <- FailedSentence{sentence, "Incomplete/out of order span sentence"}
-					count = 0
-					payload = ""
-					continue
-				}
-				count = 0
-				payload = ""
-			}
-			payload += tokens[5]
-			cache[ccount-1] = sentence
-			count++
-			if ccount == 1 { // First message in sequence, get size and id
-				size = tokens[1]
-				id = tokens[3]
-			} else if size == tokens[2] && count == ccount { // Last message in sequence, send it and clean up.
-				padding, _ = strconv.Atoi(tokens[6][:1])
-				out <- Message{MessageType(payload), payload, uint8(padding)}
-				count = 0
-				payload = ""
+	tokens := strings.Split(sentence, ",") // I think this takes the major portion of time for this function (after benchmarking)
+	if len(tokens) < 7 || len(tokens[0]) < 5 || len(tokens[5]) == 0 || len(tokens[6]) == 0 {
+		r.failed(FailedSentence{sentence, "Malformed sentence"})
+		return
+	}
+
+	if !aisIdentifiers[tokens[0][1:5]] { // Check for valid AIS identifier
+		r.failed(FailedSentence{sentence, "Sentence isn't AIVDM/AIVDO"})
+		return
+	}
+
+	if tokens[1] == "1" { // One sentence message, process it immediately
+		padding, _ := strconv.Atoi(tokens[6][:1])
+		r.single[0] = sentence
+		r.lines = r.single[:]
+		r.out(Message{Type: MessageType(tokens[5]), Payload: tokens[5], Padding: uint8(padding),
+			Timestamp: r.info.Timestamp, Source: r.info.Source})
+		if r.count > 1 { // Invalidate cache
+			for i := 0; i < r.count; i++ {
+				r.failed(FailedSentence{r.cache[i], "Incomplete/out of order span sentence"})
 			}
+			r.count = 0
+			r.payload = ""
 		}
+		return
+	}
+
+	// Message spans across sentences.
+	ccount, err := strconv.Atoi(tokens[2])
+	if err != nil || ccount < 1 || ccount > len(r.cache) {
+		r.failed(FailedSentence{sentence, "HERE " + tokens[2]})
+		return
+	}
+	if ccount != r.count+1 || // If there are sentences with wrong seq.number in cache send them as failed
+		tokens[3] != r.id && r.count != 0 || // If there are sentences with different sequence id in cache , send old parts as failed
+		tokens[1] != r.size && r.count != 0 { // If there messages with wrong size in cache, send them as failed
+		for i := 0; i < r.count; i++ {
+			r.failed(FailedSentence{r.cache[i], "Incomplete/out of order span sentence"})
+		}
+		r.count = 0
+		r.payload = ""
+		if ccount != 1 { // The current one is invalid too
+			r.failed(FailedSentence{sentence, "Incomplete/out of order span sentence"})
+			return
+		}
+	}
+	r.payload += tokens[5] // Never empty, so neither is the payload of the message
+	r.cache[ccount-1] = sentence
+	r.count++
+	if ccount == 1 { // First message in sequence, get size and id
+		r.size = tokens[1]
+		r.id = tokens[3]
+		r.first = r.info
+	} else if r.size == tokens[2] && r.count == ccount { // Last message in sequence, send it and clean up.
+		padding, _ := strconv.Atoi(tokens[6][:1])
+		r.lines = r.cache[:r.count]
+		r.out(Message{Type: MessageType(r.payload), Payload: r.payload, Padding: uint8(padding),
+			Timestamp: r.first.Timestamp, Source: r.first.Source})
+		r.count = 0
+		r.payload = ""
 	}
-	out <- Message{255, "", 0}
 }
@@ -28,16 +28,16 @@ func TestRouter(t *testing.T) {
 		sentence []string
 	}{
 		{
-			Message{3, "38u<a<?PAA2>P:WfuAO9PW<P0PuQ", 0},
+			Message{Type: 3, Payload: "38u<a<?PAA2>P:WfuAO9PW<P0PuQ", Padding: 0},
 			[]string{"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F"},
 		},
 		{
-			Message{5, "533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H51CU0E2CkP0", 2},
+			Message{Type: 5, Payload: "533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H51CU0E2CkP0", Padding: 2},
 			[]string{"!AIVDM,2,1,5,A,533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H,0*44",
 				"!AIVDM,2,2,5,A,51CU0E2CkP0,2*0C"},
 		},
 		{
-			Message{8, "85Mwom1KfI?GR<NgcvM1Hg<P2FaGjRN<S22j;WN:IDle3f5Qsq6=620c;<gvsa8P?;j>Nl0oKaCLIdeFlr<Gh@Jc95:i>c0", 2},
+			Message{Type: 8, Payload: "85Mwom1KfI?GR<NgcvM1Hg<P2FaGjRN<S22j;WN:IDle3f5Qsq6=620c;<gvsa8P?;j>Nl0oKaCLIdeFlr<Gh@Jc95:i>c0", Padding: 2},
 			[]string{"!AIVDM,3,1,7,A,85Mwom1KfI?GR<NgcvM1Hg<P2FaGjRN<S22j;WN:IDl,0*3E",
 				"!AIVDM,3,2,7,A,e3f5Qsq6=620c;<gvsa8P?;j>Nl0oKaCLIdeFlr<Gh@,0*3D",
 				"!AIVDM,3,3,7,A,Jc95:i>c0,2*08"},
@@ -63,6 +63,87 @@ func TestRouter(t *testing.T) {
 	}
 }
 
+func TestRouterWithOptions(t *testing.T) {
+	sentences := []string{
+		"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F",
+		"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F\r\n",
+		"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0",
+		"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*00",
+		"!AIVDM,1,1,,B*41",
+	}
+	cases := []struct {
+		mode     ChecksumMode
+		accepted int
+	}{
+		{ChecksumStrict, 1},
+		{ChecksumTrim, 2},
+		{ChecksumOptional, 3},
+		{ChecksumIgnore, 4},
+	}
+	want := Message{Type: 3, Payload: "38u<a<?PAA2>P:WfuAO9PW<P0PuQ", Padding: 0}
+
+	for _, c := range cases {
+		send := make(chan string)
+		receive := make(chan Message, 1024)
+		failed := make(chan FailedSentence, 1024)
+
+		go RouterWithOptions(send, receive, failed, RouterOptions{Checksum: c.mode})
+		for _, s := range sentences {
+			send <- s
+		}
+		close(send)
+
+		accepted := 0
+		for got := range receive {
+			if got.Type == 255 {
+				break
+			}
+			if got != want {
+				fmt.Println("Got : ", got)
+				fmt.Println("Want: ", want)
+				t.Errorf("RouterWithOptions(in chan string, out chan Message, failed chan FailedSentence, opts RouterOptions)")
+			}
+			accepted++
+		}
+		if accepted != c.accepted || len(failed) != len(sentences)-c.accepted {
+			t.Errorf("RouterWithOptions(..., RouterOptions{Checksum: %d}) accepted %d sentences, want %d",
+				c.mode, accepted, c.accepted)
+		}
+	}
+}
+
+func TestRouterEmptyPayload(t *testing.T) {
+	sentences := []string{
+		"!AIVDM,1,1,,A,,0*26",
+		"!AIVDM,2,1,5,A,533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H,0*44",
+		"!AIVDM,2,2,5,A,,2*11",
+		"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F",
+	}
+	want := Message{Type: 3, Payload: "38u<a<?PAA2>P:WfuAO9PW<P0PuQ", Padding: 0}
+
+	send := make(chan string)
+	receive := make(chan Message, 1024)
+	failed := make(chan FailedSentence, 1024)
+
+	go Router(send, receive, failed)
+	for _, s := range sentences {
+		send <- s
+	}
+	if got := <-receive; got != want {
+		fmt.Println("Got : ", got)
+		fmt.Println("Want: ", want)
+		t.Errorf("Router(in chan string, out chan Message, failed chan FailedSentence)")
+	}
+	if len(failed) != 2 {
+		t.Errorf("Router(in chan string, out chan Message, failed chan FailedSentence) failed %d sentences, want 2", len(failed))
+	}
+	for len(failed) > 0 {
+		if f := <-failed; f.Issue != "Malformed sentence" {
+			t.Errorf("Router(in chan string, out chan Message, failed chan FailedSentence) failed %q with %q", f.Sentence, f.Issue)
+		}
+	}
+}
+
 func BenchmarkRouter(b *testing.B) {
 	send := make(chan string)
 	receive := make(chan Message, 1024)
@@ -0,0 +1,274 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// St. Lawrence Seaway messages are binary broadcasts using the DAC of Canada (316) or of
+// the USA (366). The application data of FID 1 and FID 2 start with a 6 bit message ID,
+// which multiplexes different kinds of reports under the same FID. Each message carries
+// as many reports of its kind as fit in it.
+
+// Seaway FID 1 message IDs
+const (
+	SeawayWeatherStation = 1
+	SeawayWind           = 2
+	SeawayWaterLevel     = 3
+	SeawayWaterFlow      = 6
+)
+
+// Seaway FID 2 message IDs
+const (
+	SeawayLockageOrder      = 1
+	SeawayEstimatedLockTime = 2
+)
+
+// SeawayWeatherReport is a report of a weather station.
+type SeawayWeatherReport struct {
+	Time             time.Time // UTC, year isn't transmitted
+	StationID        string
+	Lon              float64
+	Lat              float64
+	WindSpeed        uint8  // Average wind speed in knots
+	WindGust         uint8  // knots
+	WindDirection    uint16 // degrees
+	AirTemperature   int16  // Celsius/10
+	Humidity         uint8  // Relative humidity, %
+	DewPoint         int16  // Celsius/10
+	Pressure         uint16 // hPa
+	PressureTendency uint8  // 0: steady, 1: decreasing, 2: increasing
+	Visibility       uint8  // Nautical miles/10
+}
+
+// SeawayWindReport is a report of a wind sensor.
+type SeawayWindReport struct {
+	Time          time.Time // UTC, year isn't transmitted
+	StationID     string
+	Lon           float64
+	Lat           float64
+	WindSpeed     uint8  // Average wind speed in knots
+	WindGust      uint8  // knots
+	WindDirection uint16 // degrees
+}
+
+// SeawayWaterLevelReport is a report of a water level gauge.
+type SeawayWaterLevelReport struct {
+	Time      time.Time // UTC, year isn't transmitted
+	StationID string
+	Lon       float64
+	Lat       float64
+	Level     int16 // Meters/100, relative to the datum
+	Datum     uint8 // 0: IGLD85, 1: local, 2: chart datum
+	Forecast  bool  // true if the level is forecast instead of measured
+}
+
+// SeawayWaterFlowReport is a report of a water flow gauge.
+type SeawayWaterFlowReport struct {
+	Time      time.Time // UTC, year isn't transmitted
+	StationID string
+	Lon       float64
+	Lat       float64
+	Flow      uint16 // Cubic meters per second
+}
+
+// SeawayMetHydro is a DAC 316/366, FID 1 message. Only the reports of its MessageID are set.
+type SeawayMetHydro struct {
+	BinaryHeader
+	MessageID   uint8
+	Weather     []SeawayWeatherReport
+	Wind        []SeawayWindReport
+	WaterLevels []SeawayWaterLevelReport
+	WaterFlows  []SeawayWaterFlowReport
+}
+
+// SeawayLockVessel is a vessel in a Lockage Order or Estimated Lock Times message.
+type SeawayLockVessel struct {
+	MMSI    uint32
+	Upbound bool
+	Order   uint8     // Lockage order only: position of the vessel in the lockage sequence
+	ETA     time.Time // Estimated lock times only: UTC, hour and minute
+}
+
+// SeawayLockage is a DAC 316/366, FID 2 message.
+type SeawayLockage struct {
+	BinaryHeader
+	MessageID uint8
+	Time      time.Time // UTC, year isn't transmitted
+	LockID    string
+	Vessels   []SeawayLockVessel
+}
+
+// SeawayVersion is a DAC 316/366, FID 32 message. It announces the version of the Seaway
+// messages transmitted.
+type SeawayVersion struct {
+	BinaryHeader
+	Version uint8
+}
+
+var SeawayMetHydroTypes = map[int]string{
+	SeawayWeatherStation: "Weather Station",
+	SeawayWind:           "Wind Information",
+	SeawayWaterLevel:     "Water Level",
+	SeawayWaterFlow:      "Water Flow",
+}
+
+var SeawayLockageTypes = map[int]string{
+	SeawayLockageOrder:      "Lockage Order",
+	SeawayEstimatedLockTime: "Estimated Lock Times",
+}
+
+// seawayReportHeader decodes the time, station ID and position every FID 1 report starts with.
+// It spans 111 bits.
+func seawayReportHeader(first int, r *BitReader) (time.Time, string, float64, float64) {
+	t := cbnMonthDayTime(first, r)
+
+	station := r.String(first+20, first+61)
+
+	lon, lat := cbnCoordinatesLow(first+62, r)
+
+	return t, station, lon, lat
+}
+
+// isSeaway checks whether a binary message is a Seaway message with a certain FID.
+func isSeaway(h BinaryHeader, fid uint8) bool {
+	return h.Type == 8 && (h.DAC == 316 || h.DAC == 366) && h.FID == fid
+}
+
+// DecodeSeawayMetHydro decodes the payload of a Seaway Weather Station, Wind or Water Level
+// message (type 8 DAC 316/366 FID 1).
+func DecodeSeawayMetHydro(payload string) (SeawayMetHydro, error) {
+	r := NewBitReader(payload)
+	var m SeawayMetHydro
+
+	m.BinaryHeader, _ = decodeBinaryHeader(r)
+	if !isSeaway(m.BinaryHeader, 1) {
+		return m, errors.New("Message isn't Seaway Meteorological/Hydrological (type 8, DAC 316/366, FID 1).")
+	}
+
+	m.MessageID = uint8(r.Uint(56, 61))
+
+	size := r.Len()
+	switch m.MessageID {
+	case SeawayWeatherStation:
+		for first := 62; first+181 <= size; first += 181 {
+			var rep SeawayWeatherReport
+			rep.Time, rep.StationID, rep.Lon, rep.Lat = seawayReportHeader(first, r)
+			rep.WindSpeed = uint8(r.Uint(first+111, first+117))
+			rep.WindGust = uint8(r.Uint(first+118, first+124))
+			rep.WindDirection = uint16(r.Uint(first+125, first+133))
+			rep.AirTemperature = int16(r.Int(first+134, first+144))
+			rep.Humidity = uint8(r.Uint(first+145, first+151))
+			rep.DewPoint = int16(r.Int(first+152, first+161))
+			rep.Pressure = uint16(r.Uint(first+162, first+170)) + 800
+			rep.PressureTendency = uint8(r.Uint(first+171, first+172))
+			rep.Visibility = uint8(r.Uint(first+173, first+180))
+			m.Weather = append(m.Weather, rep)
+		}
+	case SeawayWind:
+		for first := 62; first+134 <= size; first += 134 {
+			var rep SeawayWindReport
+			rep.Time, rep.StationID, rep.Lon, rep.Lat = seawayReportHeader(first, r)
+			rep.WindSpeed = uint8(r.Uint(first+111, first+117))
+			rep.WindGust = uint8(r.Uint(first+118, first+124))
+			rep.WindDirection = uint16(r.Uint(first+125, first+133))
+			m.Wind = append(m.Wind, rep)
+		}
+	case SeawayWaterLevel:
+		for first := 62; first+130 <= size; first += 130 {
+			var rep SeawayWaterLevelReport
+			rep.Time, rep.StationID, rep.Lon, rep.Lat = seawayReportHeader(first, r)
+			rep.Level = int16(r.Int(first+111, first+126))
+			rep.Datum = uint8(r.Uint(first+127, first+128))
+			rep.Forecast = r.Bool(first + 129)
+			m.WaterLevels = append(m.WaterLevels, rep)
+		}
+	case SeawayWaterFlow:
+		for first := 62; first+125 <= size; first += 125 {
+			var rep SeawayWaterFlowReport
+			rep.Time, rep.StationID, rep.Lon, rep.Lat = seawayReportHeader(first, r)
+			rep.Flow = uint16(r.Uint(first+111, first+124))
+			m.WaterFlows = append(m.WaterFlows, rep)
+		}
+	default:
+		return m, errors.New("Unsupported Seaway FID 1 message ID.")
+	}
+
+	return m, nil
+}
+
+// DecodeSeawayLockage decodes the payload of a Seaway Lockage Order or Estimated Lock Times
+// message (type 8 DAC 316/366 FID 2).
+func DecodeSeawayLockage(payload string) (SeawayLockage, error) {
+	r := NewBitReader(payload)
+	var m SeawayLockage
+
+	m.BinaryHeader, _ = decodeBinaryHeader(r)
+	if !isSeaway(m.BinaryHeader, 2) {
+		return m, errors.New("Message isn't Seaway Lockage (type 8, DAC 316/366, FID 2).")
+	}
+
+	m.MessageID = uint8(r.Uint(56, 61))
+
+	m.Time = cbnMonthDayTime(62, r)
+
+	m.LockID = r.String(82, 123)
+
+	size := r.Len()
+	switch m.MessageID {
+	case SeawayLockageOrder:
+		for first := 124; first+35 <= size; first += 35 {
+			var v SeawayLockVessel
+			v.Order = uint8(r.Uint(first, first+3))
+			v.MMSI = uint32(r.Uint(first+4, first+33))
+			v.Upbound = r.Bool(first + 34)
+			m.Vessels = append(m.Vessels, v)
+		}
+	case SeawayEstimatedLockTime:
+		for first := 124; first+42 <= size; first += 42 {
+			var v SeawayLockVessel
+			v.MMSI = uint32(r.Uint(first, first+29))
+			v.Upbound = r.Bool(first + 30)
+			timeString := fmt.Sprintf("%d:%d", r.Uint(first+31, first+35), r.Uint(first+36, first+41))
+			v.ETA, _ = time.Parse("15:4", timeString)
+			m.Vessels = append(m.Vessels, v)
+		}
+	default:
+		return m, errors.New("Unsupported Seaway FID 2 message ID.")
+	}
+
+	return m, nil
+}
+
+// DecodeSeawayVersion decodes the payload of a Seaway Version message (type 8 DAC 316/366 FID 32).
+func DecodeSeawayVersion(payload string) (SeawayVersion, error) {
+	r := NewBitReader(payload)
+	var m SeawayVersion
+
+	m.BinaryHeader, _ = decodeBinaryHeader(r)
+	if !isSeaway(m.BinaryHeader, 32) {
+		return m, errors.New("Message isn't Seaway Version (type 8, DAC 316/366, FID 32).")
+	}
+
+	m.Version = uint8(r.Uint(56, 61))
+
+	return m, nil
+}
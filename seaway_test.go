@@ -0,0 +1,101 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// I didn't find captured Seaway messages, so these payloads were composed by hand.
+func TestDecodeSeawayMetHydro(t *testing.T) {
+	caseTime1, _ := time.Parse("1/2 15:4", "7/21 13:45")
+	caseTime2, _ := time.Parse("1/2 15:4", "7/21 13:40")
+	cases := []struct {
+		payload string
+		want    SeawayMetHydro
+	}{
+		{
+			"8030p?i?0@GbnlU8u5DtWMQ`@DQRh<9?DJt0SVc:cmK@VL:HH01fpj>:C524So=w6?s3F7P",
+			SeawayMetHydro{
+				BinaryHeader: BinaryHeader{Type: 8, Repeat: 0, MMSI: 3160127, DAC: 316, FID: 1},
+				MessageID:    SeawayWeatherStation,
+				Weather: []SeawayWeatherReport{
+					{Time: caseTime1, StationID: "IROQUOI", Lon: -75.3108, Lat: 44.8356, WindSpeed: 12,
+						WindGust: 18, WindDirection: 245, AirTemperature: 215, Humidity: 64, DewPoint: 142,
+						Pressure: 1013, PressureTendency: 2, Visibility: 85},
+					{Time: caseTime2, StationID: "SNELL", Lon: -74.7667, Lat: 44.9931, WindSpeed: 9,
+						WindGust: 15, WindDirection: 230, AirTemperature: -15, Humidity: 71, DewPoint: -40,
+						Pressure: 1014, PressureTendency: 0, Visibility: 120},
+				},
+			},
+		},
+		{
+			"803OwsiKP@obnlDU<DpPwMftlDVCmwu9",
+			SeawayMetHydro{
+				BinaryHeader: BinaryHeader{Type: 8, Repeat: 0, MMSI: 3669999, DAC: 366, FID: 1},
+				MessageID:    SeawayWaterLevel,
+				WaterLevels: []SeawayWaterLevelReport{
+					{Time: caseTime1, StationID: "EISENHO", Lon: -74.8561, Lat: 44.9983, Level: -23,
+						Datum: 0, Forecast: true},
+				},
+			},
+		},
+	}
+	for _, c := range cases {
+		got, _ := DecodeSeawayMetHydro(c.payload)
+		if !reflect.DeepEqual(got, c.want) {
+			fmt.Println("Got : ", got)
+			fmt.Println("Want: ", c.want)
+			t.Errorf("DecodeSeawayMetHydro(payload string)")
+		}
+	}
+}
+
+func TestDecodeSeawayLockage(t *testing.T) {
+	caseTime, _ := time.Parse("1/2 15:4", "7/21 14:5")
+	want := SeawayLockage{
+		BinaryHeader: BinaryHeader{Type: 8, Repeat: 0, MMSI: 3160127, DAC: 316, FID: 2},
+		MessageID:    SeawayLockageOrder, Time: caseTime, LockID: "SLOCK7",
+		Vessels: []SeawayLockVessel{
+			{MMSI: 316001234, Upbound: true, Order: 1},
+			{MMSI: 366987654, Upbound: false, Order: 2},
+		},
+	}
+	got, _ := DecodeSeawayLockage("8030p?i?0PGbpE<ht<gL0DeG;la:svC30")
+	if !reflect.DeepEqual(got, want) {
+		fmt.Println("Got : ", got)
+		fmt.Println("Want: ", want)
+		t.Errorf("DecodeSeawayLockage(payload string)")
+	}
+}
+
+func TestDecodeSeawayVersion(t *testing.T) {
+	want := SeawayVersion{
+		BinaryHeader: BinaryHeader{Type: 8, Repeat: 0, MMSI: 3160127, DAC: 316, FID: 32},
+		Version:      3,
+	}
+	got, _ := DecodeSeawayVersion("8030p?i?80h")
+	if got != want {
+		fmt.Println("Got : ", got)
+		fmt.Println("Want: ", want)
+		t.Errorf("DecodeSeawayVersion(payload string)")
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// MaxSentenceLength is the maximum length of an NMEA183 sentence, not counting the
+// terminating <CR><LF>.
+const MaxSentenceLength = 80
+
+// A SentenceWriter wraps AIS messages into AIVDM/AIVDO radio sentences, the inverse of Router.
+// Messages that don't fit into one sentence are split, and each multi-sentence message gets
+// the next sequential message ID (0-9). It is safe to use from many goroutines.
+type SentenceWriter struct {
+	Talker  string // Talker ID, e.g "AI" or "AB"
+	Own     bool   // If true, sentences are AIVDO (own vessel) instead of AIVDM
+	Channel string // Radio channel: "A", "B" or empty
+
+	mu sync.Mutex
+	id int
+}
+
+// NewSentenceWriter returns a SentenceWriter for the given talker ID and radio channel.
+func NewSentenceWriter(talker string, own bool, channel string) *SentenceWriter {
+	return &SentenceWriter{Talker: talker, Own: own, Channel: channel}
+}
+
+// Sentences returns the radio sentences that carry a message, without <CR><LF>.
+func (w *SentenceWriter) Sentences(m Message) ([]string, error) {
+	formatter := "VDM"
+	if w.Own {
+		formatter = "VDO"
+	}
+	if len(w.Talker) != 2 || !aisIdentifiers[w.Talker+formatter[:2]] {
+		return nil, errors.New("Talker ID '" + w.Talker + "' isn't valid for AIS.")
+	}
+	if len(w.Channel) > 1 {
+		return nil, errors.New("Channel '" + w.Channel + "' isn't valid.")
+	}
+	if m.Padding > 5 {
+		return nil, errors.New("Padding should be between 0 and 5.")
+	}
+	if len(m.Payload) == 0 {
+		return nil, errors.New("Message has no payload.")
+	}
+
+	// Everything but the payload takes the same space in every sentence
+	overhead := len(fmt.Sprintf("!%s%s,1,1,0,%s,,0*00", w.Talker, formatter, w.Channel))
+	size := MaxSentenceLength - overhead
+	count := (len(m.Payload) + size - 1) / size
+	if count > 9 {
+		return nil, errors.New("Payload is too long, it needs more than 9 sentences.")
+	}
+
+	id := ""
+	if count > 1 {
+		w.mu.Lock()
+		id = fmt.Sprint(w.id)
+		w.id = (w.id + 1) % 10
+		w.mu.Unlock()
+	}
+
+	sentences := make([]string, count)
+	for i := range sentences {
+		payload := m.Payload[i*size:]
+		padding := uint8(0)
+		if len(payload) > size {
+			payload = payload[:size]
+		} else {
+			padding = m.Padding // Only the last sentence has fill bits
+		}
+		data := fmt.Sprintf("%s%s,%d,%d,%s,%s,%s,%d", w.Talker, formatter, count, i+1, id,
+			w.Channel, payload, padding)
+		sentences[i] = fmt.Sprintf("!%s*%02X", data, Nmea183Checksum(data))
+	}
+	return sentences, nil
+}
+
+// Write writes the radio sentences of a message to out, each terminated by <CR><LF>, as
+// chart plotters and other NMEA183 listeners expect.
+func (w *SentenceWriter) Write(out io.Writer, m Message) error {
+	sentences, err := w.Sentences(m)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(out, strings.Join(sentences, "\r\n")+"\r\n")
+	return err
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSentenceWriter(t *testing.T) {
+	cases := []struct {
+		message Message
+		id      int
+		want    []string
+	}{
+		{
+			Message{Type: 3, Payload: "38u<a<?PAA2>P:WfuAO9PW<P0PuQ", Padding: 0},
+			0,
+			[]string{"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F"},
+		},
+		{
+			Message{Type: 5, Payload: "533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H51CU0E2CkP0", Padding: 2},
+			5,
+			[]string{"!AIVDM,2,1,5,B,533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H,0*47",
+				"!AIVDM,2,2,5,B,51CU0E2CkP0,2*0F"},
+		},
+	}
+
+	w := NewSentenceWriter("AI", false, "B")
+	for _, c := range cases {
+		w.id = c.id
+		got, err := w.Sentences(c.message)
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			fmt.Println("Got : ", got, err)
+			fmt.Println("Want: ", c.want)
+			t.Errorf("SentenceWriter.Sentences(m Message)")
+		}
+		for _, s := range got {
+			if !Nmea183ChecksumCheck(s) || len(s) > MaxSentenceLength {
+				t.Errorf("SentenceWriter.Sentences(m Message) returned invalid sentence %s", s)
+			}
+		}
+	}
+
+	// Sequential message IDs wrap after 9
+	w.id = 9
+	got, _ := w.Sentences(cases[1].message)
+	next, _ := w.Sentences(cases[1].message)
+	if !strings.HasPrefix(got[0], "!AIVDM,2,1,9,B,") || !strings.HasPrefix(next[0], "!AIVDM,2,1,0,B,") {
+		t.Errorf("SentenceWriter.Sentences(m Message) message IDs %s, %s", got[0], next[0])
+	}
+
+	var out bytes.Buffer
+	w = NewSentenceWriter("AB", true, "")
+	if err := w.Write(&out, cases[0].message); err != nil || out.String() != "!ABVDO,1,1,,,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*24\r\n" {
+		t.Errorf("SentenceWriter.Write(out io.Writer, m Message) = %q, %v", out.String(), err)
+	}
+
+	invalid := []struct {
+		w *SentenceWriter
+		m Message
+	}{
+		{NewSentenceWriter("GP", false, "A"), cases[0].message},
+		{NewSentenceWriter("AI", false, "AB"), cases[0].message},
+		{NewSentenceWriter("AI", false, "A"), Message{Type: 8, Payload: strings.Repeat("0", 9*60+1), Padding: 0}},
+		{NewSentenceWriter("AI", false, "A"), Message{Type: 3, Payload: "38u<a<?PAA2>P:WfuAO9PW<P0PuQ", Padding: 6}},
+		{NewSentenceWriter("AI", false, "A"), Message{}},
+	}
+	for _, c := range invalid {
+		if _, err := c.w.Sentences(c.m); err == nil {
+			t.Errorf("SentenceWriter.Sentences(m Message) accepted %v, %v", c.w, c.m)
+		}
+	}
+}
+
+func TestSentenceWriterRouter(t *testing.T) {
+	messages := []Message{
+		{Type: 3, Payload: "38u<a<?PAA2>P:WfuAO9PW<P0PuQ", Padding: 0},
+		{Type: 8, Payload: "85Mwom1KfI?GR<NgcvM1Hg<P2FaGjRN<S22j;WN:IDle3f5Qsq6=620c;<gvsa8P?;j>Nl0oKaCLIdeFlr<Gh@Jc95:i>c0", Padding: 2},
+		{Type: 8, Payload: strings.Repeat("85Mwp`00GH181B2?", 30), Padding: 4},
+	}
+
+	send := make(chan string)
+	receive := make(chan Message, 1024)
+	failed := make(chan FailedSentence, 1024)
+	go Router(send, receive, failed)
+
+	w := NewSentenceWriter("AI", false, "A")
+	for _, m := range messages {
+		sentences, _ := w.Sentences(m)
+		for _, s := range sentences {
+			send <- s
+		}
+		if got := <-receive; got != m {
+			fmt.Println("Got : ", got)
+			fmt.Println("Want: ", m)
+			t.Errorf("Router(SentenceWriter.Sentences(m Message))")
+		}
+	}
+	close(send)
+}
+
+func BenchmarkSentenceWriter(b *testing.B) {
+	w := NewSentenceWriter("AI", false, "A")
+	m := Message{Type: 5, Payload: "533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H51CU0E2CkP0", Padding: 2}
+	for i := 0; i < b.N; i++ {
+		w.Sentences(m)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// Parity is the parity setting of a serial port.
+type Parity int
+
+// Parity settings.
+const (
+	ParityNone Parity = iota
+	ParityEven
+	ParityOdd
+)
+
+// DefaultBaudRate is the speed of AIS transponders (IEC 61162-2), used if Baud isn't set.
+const DefaultBaudRate = 38400
+
+// A SerialPort reads NMEA sentences from a character device, e.g the serial port of an AIS
+// transponder (/dev/ttyUSB0) or a pseudo-terminal, and delivers them line by line. The line is
+// configured as raw 8-bit (7-bit with parity) at Baud. Lines may arrive in pieces; they are
+// delivered once complete. When the device fails (e.g it is unplugged) it is reopened, waiting
+// with exponential backoff from MinBackoff up to MaxBackoff, like TCPClient.
+//
+// Serial ports are supported on Linux only. Set the fields before calling Run. Zero values
+// take the defaults.
+type SerialPort struct {
+	Device   string
+	Baud     int
+	Parity   Parity
+	StopBits int // 1 or 2
+
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// ReadTimeout, if set, makes a device that delivers nothing for so long count as failed.
+	ReadTimeout time.Duration
+
+	// MaxLineLength, if set, overrides DefaultMaxLineLength.
+	MaxLineLength int
+
+	// OnStateChange, if set, is called on every state change with the error that caused it,
+	// if any. It is called from the goroutine of Run, so it should return quickly.
+	OnStateChange func(device string, state SourceState, err error)
+
+	counters sourceCounters
+	running  int32
+}
+
+// NewSerialPort returns a SerialPort for device at baud, without parity and one stop bit.
+func NewSerialPort(device string, baud int) *SerialPort {
+	return &SerialPort{Device: device, Baud: baud}
+}
+
+// Stats returns the counters of the port. Connects are the times the device was opened. It is
+// safe to call while Run is running.
+func (p *SerialPort) Stats() SourceStats {
+	return p.counters.get()
+}
+
+func (p *SerialPort) setState(state SourceState, err error) {
+	if p.OnStateChange != nil {
+		p.OnStateChange(p.Device, state, err)
+	}
+}
+
+// Run opens the device and sends the lines it reads to out (e.g the in channel of a Router),
+// until ctx is cancelled. Then it returns the context's error. A port can run only once at a
+// time.
+func (p *SerialPort) Run(ctx context.Context, out chan<- string) error {
+	if !atomic.CompareAndSwapInt32(&p.running, 0, 1) {
+		return errors.New("SerialPort is already running.")
+	}
+	defer atomic.StoreInt32(&p.running, 0)
+
+	return runSource(ctx, p.MinBackoff, p.MaxBackoff, &p.counters, p.setState, func() (bool, error) {
+		return p.open(ctx, out)
+	})
+}
+
+// open opens and configures the device once and reads it until it fails. It reports if any
+// data were received.
+func (p *SerialPort) open(ctx context.Context, out chan<- string) (bool, error) {
+	f, err := openSerial(p.Device, p.Baud, p.Parity, p.StopBits)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	p.counters.update(func(s *SourceStats) { s.Connects++ })
+	p.setState(StateConnected, nil)
+
+	// Unblock reads when the context is cancelled
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			f.Close()
+		case <-stop:
+		}
+	}()
+
+	return readLines(ctx, &deadlineReader{f, p.ReadTimeout, &p.counters}, p.MaxLineLength, &p.counters, out)
+}
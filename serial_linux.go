@@ -0,0 +1,106 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// baudRates are the termios speeds of the supported baud rates.
+var baudRates = map[int]uint32{
+	1200: syscall.B1200, 2400: syscall.B2400, 4800: syscall.B4800, 9600: syscall.B9600,
+	19200: syscall.B19200, 38400: syscall.B38400, 57600: syscall.B57600,
+	115200: syscall.B115200, 230400: syscall.B230400, 460800: syscall.B460800,
+}
+
+// openSerial opens a serial device and configures it as a raw line.
+func openSerial(device string, baud int, parity Parity, stopBits int) (*os.File, error) {
+	t, err := serialTermios(baud, parity, stopBits)
+	if err != nil {
+		return nil, err
+	}
+
+	// The file is opened non-blocking, so its reads go through the runtime poller and can be
+	// interrupted with Close and deadlines
+	f, err := os.OpenFile(device, os.O_RDWR|syscall.O_NOCTTY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := ioctl(f, syscall.TCSETS, unsafe.Pointer(&t)); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// serialTermios returns the settings of a raw line.
+func serialTermios(baud int, parity Parity, stopBits int) (syscall.Termios, error) {
+	if baud == 0 {
+		baud = DefaultBaudRate
+	}
+	speed, ok := baudRates[baud]
+	if !ok {
+		return syscall.Termios{}, errors.New("Unsupported baud rate.")
+	}
+
+	// The speed goes in the CBAUD bits only, some architectures (mips) have no speed fields
+	t := syscall.Termios{Cflag: speed | syscall.CREAD | syscall.CLOCAL}
+	t.Cc[syscall.VMIN] = 1
+	switch parity {
+	case ParityNone:
+		t.Cflag |= syscall.CS8
+		t.Iflag = syscall.IGNPAR
+	case ParityEven, ParityOdd:
+		t.Cflag |= syscall.CS7 | syscall.PARENB
+		if parity == ParityOdd {
+			t.Cflag |= syscall.PARODD
+		}
+		t.Iflag = syscall.INPCK | syscall.ISTRIP
+	default:
+		return syscall.Termios{}, errors.New("Unsupported parity.")
+	}
+	switch stopBits {
+	case 0, 1:
+	case 2:
+		t.Cflag |= syscall.CSTOPB
+	default:
+		return syscall.Termios{}, errors.New("Unsupported number of stop bits.")
+	}
+	return t, nil
+}
+
+// ioctl calls ioctl on the descriptor of f.
+func ioctl(f *os.File, request uintptr, arg unsafe.Pointer) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	if err := conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(arg))
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
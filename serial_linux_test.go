@@ -0,0 +1,179 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// openPty opens a pseudo-terminal and returns its master and the path of its slave, which
+// plays the serial device.
+func openPty(t *testing.T) (*os.File, string) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skip("No pseudo-terminals: ", err)
+	}
+	var unlock int32
+	if err := ioctl(master, syscall.TIOCSPTLCK, unsafe.Pointer(&unlock)); err != nil {
+		master.Close()
+		t.Fatal(err)
+	}
+	var n uint32
+	if err := ioctl(master, syscall.TIOCGPTN, unsafe.Pointer(&n)); err != nil {
+		master.Close()
+		t.Fatal(err)
+	}
+	return master, fmt.Sprintf("/dev/pts/%d", n)
+}
+
+func TestSerialPort(t *testing.T) {
+	master, slave := openPty(t)
+	defer func() { master.Close() }()
+
+	// The port opens a link, so the device can be replaced
+	device := filepath.Join(t.TempDir(), "ttyAIS")
+	if err := os.Symlink(slave, device); err != nil {
+		t.Fatal(err)
+	}
+
+	var rec stateRecorder
+	p := NewSerialPort(device, 38400)
+	p.MinBackoff = 10 * time.Millisecond
+	p.MaxBackoff = 20 * time.Millisecond
+	p.OnStateChange = rec.record
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan string)
+	done := make(chan error)
+	go func() { done <- p.Run(ctx, out) }()
+	waitFor(t, "device", func() bool { return p.Stats().Connects == 1 })
+
+	receive := func(want string) {
+		select {
+		case got := <-out:
+			if got != want {
+				t.Errorf("SerialPort.Run() sent %q, want %q", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("SerialPort.Run() didn't send %q", want)
+		}
+	}
+
+	// A sentence in pieces
+	master.Write([]byte("!AIVDM,1,1,,B,38u<a<?PAA2>P:"))
+	time.Sleep(20 * time.Millisecond)
+	master.Write([]byte("WfuAO9PW<P0PuQ,0*6F\r\n\r\nline 2\n"))
+	receive("!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F")
+	receive("line 2")
+
+	// The device goes away and comes back as another pseudo-terminal
+	master.Close()
+	waitFor(t, "failure", func() bool { return p.Stats().Failures >= 1 })
+	master, slave = openPty(t)
+	os.Remove(device)
+	if err := os.Symlink(slave, device); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "reopen", func() bool { return p.Stats().Connects == 2 })
+	master.Write([]byte("line 3\n"))
+	receive("line 3")
+
+	if err := p.Run(ctx, out); err == nil {
+		t.Errorf("SerialPort.Run() ran twice at the same time")
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("SerialPort.Run() = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("SerialPort.Run() didn't stop when cancelled")
+	}
+
+	states, _, _ := rec.get()
+	if states[0] != StateConnecting || states[1] != StateConnected || states[2] != StateDisconnected ||
+		states[len(states)-1] != StateStopped {
+		t.Errorf("SerialPort states %v", states)
+	}
+	if stats := p.Stats(); stats.Lines != 3 {
+		t.Errorf("SerialPort.Stats() = %+v", stats)
+	}
+}
+
+func TestSerialTermios(t *testing.T) {
+	cases := []struct {
+		baud     int
+		parity   Parity
+		stopBits int
+		cflag    uint32
+		iflag    uint32
+	}{
+		{0, ParityNone, 1, syscall.B38400 | syscall.CS8 | syscall.CREAD | syscall.CLOCAL, syscall.IGNPAR},
+		{4800, ParityEven, 1, syscall.B4800 | syscall.CS7 | syscall.PARENB | syscall.CREAD | syscall.CLOCAL,
+			syscall.INPCK | syscall.ISTRIP},
+		{9600, ParityOdd, 2, syscall.B9600 | syscall.CS7 | syscall.PARENB | syscall.PARODD | syscall.CSTOPB |
+			syscall.CREAD | syscall.CLOCAL, syscall.INPCK | syscall.ISTRIP},
+	}
+	for _, c := range cases {
+		got, err := serialTermios(c.baud, c.parity, c.stopBits)
+		if err != nil || got.Cflag != c.cflag || got.Iflag != c.iflag || got.Lflag != 0 || got.Oflag != 0 ||
+			got.Cc[syscall.VMIN] != 1 {
+			fmt.Println("Got : ", got, err)
+			fmt.Printf("Want: cflag %o iflag %o\n", c.cflag, c.iflag)
+			t.Errorf("serialTermios(baud int, parity Parity, stopBits int)")
+		}
+	}
+
+	for _, c := range [][3]int{{12345, 0, 1}, {38400, 3, 1}, {38400, 0, 3}} {
+		if _, err := serialTermios(c[0], Parity(c[1]), c[2]); err == nil {
+			t.Errorf("serialTermios(%d, %d, %d) accepted bad settings", c[0], c[1], c[2])
+		}
+	}
+}
+
+func TestOpenSerial(t *testing.T) {
+	master, slave := openPty(t)
+	defer master.Close()
+
+	// Pseudo-terminals keep the speed and stop bits, but always use 8 bits without parity
+	f, err := openSerial(slave, 9600, ParityNone, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var got syscall.Termios
+	if err := ioctl(f, syscall.TCGETS, unsafe.Pointer(&got)); err != nil {
+		t.Fatal(err)
+	}
+	want := uint32(syscall.B9600 | syscall.CSTOPB | syscall.CS8)
+	if got.Cflag&want != want || got.Lflag&(syscall.ICANON|syscall.ECHO) != 0 {
+		t.Errorf("openSerial(device string, baud int, parity Parity, stopBits int) set cflag %o, lflag %o", got.Cflag, got.Lflag)
+	}
+
+	if _, err := openSerial(slave, 12345, ParityNone, 1); err == nil {
+		t.Errorf("openSerial(device string, baud int, parity Parity, stopBits int) accepted a bad baud rate")
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+//go:build !linux
+
+package aislib
+
+import (
+	"errors"
+	"os"
+)
+
+// openSerial isn't implemented outside Linux.
+func openSerial(device string, baud int, parity Parity, stopBits int) (*os.File, error) {
+	return nil, errors.New("Serial ports are only supported on Linux.")
+}
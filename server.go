@@ -0,0 +1,342 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A BoundingBox is an area in decimal degrees. Boxes that cross the antimeridian have
+// MinLon > MaxLon.
+type BoundingBox struct {
+	MinLon, MinLat, MaxLon, MaxLat float64
+}
+
+// Contains reports if a point is inside the box.
+func (b BoundingBox) Contains(lon, lat float64) bool {
+	if lat < b.MinLat || lat > b.MaxLat {
+		return false
+	}
+	if b.MinLon <= b.MaxLon {
+		return lon >= b.MinLon && lon <= b.MaxLon
+	}
+	return lon >= b.MinLon || lon <= b.MaxLon
+}
+
+// A Filter selects the messages a client of a Server receives. Empty fields match everything.
+type Filter struct {
+	Types []uint8
+	MMSIs []uint32
+	// Box, if set, selects messages from stations inside it. Messages without a position (e.g
+	// static data) use the last position the server saw for their MMSI, so they are dropped
+	// until a position of the station arrives.
+	Box *BoundingBox
+}
+
+// match reports if the filter selects a message of a station with the given position.
+func (f Filter) match(m Message, mmsi uint32, lon, lat float64, located bool) bool {
+	if len(f.Types) > 0 {
+		found := false
+		for _, t := range f.Types {
+			found = found || t == m.Type
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.MMSIs) > 0 {
+		found := false
+		for _, id := range f.MMSIs {
+			found = found || id == mmsi
+		}
+		if !found {
+			return false
+		}
+	}
+	return f.Box == nil || located && f.Box.Contains(lon, lat)
+}
+
+// Defaults of Server.
+const (
+	DefaultClientBuffer = 256
+	DefaultWriteTimeout = 10 * time.Second
+)
+
+// A Server relays NMEA sentences to TCP clients, e.g chart plotters, so a single process
+// can read the receivers. Feed it with Broadcast. Only the sentences of AIS messages are relayed,
+// without archive decoration, and the sentences of a multi-sentence message are written
+// together, so clients never see them interleaved.
+//
+// Broadcast never blocks: every client has a queue of ClientBuffer messages and a client that
+// can't keep up is disconnected.
+//
+// The server keeps the last position of every station for the box filters; call Expire from time
+// to time to forget the stations that went silent.
+//
+// Set the fields before calling Serve. Zero values take the defaults.
+type Server struct {
+	// Options are the RouterOptions used to assemble the messages.
+	Options RouterOptions
+	// Filter, if set, returns the filter of a new client.
+	Filter func(remote net.Addr) Filter
+	// OnClient, if set, is called when a client connects or disconnects, with the reason. It is
+	// called from the goroutines of the server and Broadcast, so it should return quickly.
+	OnClient func(remote net.Addr, connected bool, err error)
+
+	ClientBuffer int
+	WriteTimeout time.Duration
+
+	mu        sync.Mutex
+	router    *router
+	clients   map[*serverClient]bool
+	positions map[uint32]serverPosition // Last position of every station, for box filters
+	dropped   int64
+	removed   []removedClient
+}
+
+// serverPosition is the last position of a station and when it was last heard.
+type serverPosition struct {
+	lon, lat float64
+	seen     time.Time
+}
+
+// serverClient is a client connected to a Server.
+type serverClient struct {
+	conn    net.Conn
+	filter  Filter
+	queue   chan string
+	done    chan struct{}
+	closing sync.Once
+}
+
+// NewServer returns a Server that assembles messages with opts.
+func NewServer(opts RouterOptions) *Server {
+	return &Server{Options: opts}
+}
+
+// Clients returns the number of connected clients.
+func (s *Server) Clients() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients)
+}
+
+// Dropped returns the number of clients that were disconnected for being too slow.
+func (s *Server) Dropped() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// init prepares the state of the server. It must be called with mu held.
+func (s *Server) init() {
+	if s.clients != nil {
+		return
+	}
+	s.clients = make(map[*serverClient]bool)
+	s.positions = make(map[uint32]serverPosition)
+	s.router = newRouter(s.Options, s.relay, func(FailedSentence) {})
+}
+
+// Expire forgets the positions of the stations not heard since a time and returns how many
+// were forgotten. Until they report a position again, box filters drop their messages.
+func (s *Server) Expire(before time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for mmsi, p := range s.positions {
+		if p.seen.Before(before) {
+			delete(s.positions, mmsi)
+			n++
+		}
+	}
+	return n
+}
+
+// Broadcast processes a line (as Router would) and relays the sentences of every message
+// it completes to the clients it matches. It is safe for concurrent use.
+func (s *Server) Broadcast(line string) {
+	s.mu.Lock()
+	defer s.unlock()
+	s.init()
+	s.router.process(line)
+}
+
+// relay is the out function of the router of the server. It is called with mu held.
+func (s *Server) relay(m Message) {
+	now := m.Timestamp
+	if now.IsZero() {
+		now = time.Now()
+	}
+	mmsi := MessageMMSI(m.Payload)
+	lon, lat, located := MessagePosition(m.Payload)
+	if located {
+		s.positions[mmsi] = serverPosition{lon, lat, now}
+	} else if p, ok := s.positions[mmsi]; ok {
+		lon, lat, located = p.lon, p.lat, true
+		if now.After(p.seen) {
+			p.seen = now
+			s.positions[mmsi] = p
+		}
+	}
+
+	text := ""
+	for c := range s.clients {
+		if !c.filter.match(m, mmsi, lon, lat, located) {
+			continue
+		}
+		if text == "" {
+			text = strings.Join(s.router.lines, "\r\n") + "\r\n"
+		}
+		select {
+		case c.queue <- text:
+		default:
+			s.dropped++
+			s.remove(c, errSlowClient)
+		}
+	}
+}
+
+// errSlowClient is the reason a slow client was disconnected.
+var errSlowClient = errors.New("Client too slow.")
+
+// remove disconnects a client. It is called with mu held; OnClient is called by unlock.
+func (s *Server) remove(c *serverClient, err error) {
+	if !s.clients[c] {
+		return
+	}
+	delete(s.clients, c)
+	c.closing.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+	s.removed = append(s.removed, removedClient{c.conn.RemoteAddr(), err})
+}
+
+// removedClient is a client that was disconnected, waiting to be reported to OnClient.
+type removedClient struct {
+	remote net.Addr
+	err    error
+}
+
+// unlock releases mu and reports the disconnected clients, so OnClient may call the server.
+func (s *Server) unlock() {
+	removed := s.removed
+	s.removed = nil
+	s.mu.Unlock()
+	if s.OnClient != nil {
+		for _, r := range removed {
+			s.OnClient(r.remote, false, r.err)
+		}
+	}
+}
+
+// Serve accepts clients from ln until ctx is cancelled or ln fails. Then it closes ln,
+// disconnects the clients and returns the context's error or the error of ln.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			ln.Close()
+		case <-stop:
+		}
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer func() {
+		s.mu.Lock()
+		for c := range s.clients {
+			s.remove(c, ctx.Err())
+		}
+		s.unlock()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			ln.Close()
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		size := s.ClientBuffer
+		if size <= 0 {
+			size = DefaultClientBuffer
+		}
+		c := &serverClient{conn: conn, queue: make(chan string, size), done: make(chan struct{})}
+		if s.Filter != nil {
+			c.filter = s.Filter(conn.RemoteAddr())
+		}
+		s.mu.Lock()
+		s.init()
+		s.clients[c] = true
+		s.mu.Unlock()
+		if s.OnClient != nil {
+			s.OnClient(conn.RemoteAddr(), true, nil)
+		}
+
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			s.write(c)
+		}()
+		go func() { // Notice when the client goes away
+			defer wg.Done()
+			_, err := io.Copy(io.Discard, c.conn)
+			if err == nil {
+				err = io.EOF
+			}
+			s.mu.Lock()
+			s.remove(c, err)
+			s.unlock()
+		}()
+	}
+}
+
+// write writes the queue of a client to its connection until the client is removed.
+func (s *Server) write(c *serverClient) {
+	timeout := s.WriteTimeout
+	if timeout <= 0 {
+		timeout = DefaultWriteTimeout
+	}
+	for {
+		select {
+		case text := <-c.queue:
+			c.conn.SetWriteDeadline(time.Now().Add(timeout))
+			if _, err := io.WriteString(c.conn, text); err != nil {
+				s.mu.Lock()
+				s.remove(c, err)
+				s.unlock()
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testSentence wraps a payload into a single sentence with a valid checksum.
+func testSentence(payload string) string {
+	s := "!AIVDM,1,1,,B," + payload + ",0"
+	return fmt.Sprintf("%s*%02X", s, Nmea183Checksum(s))
+}
+
+// waitFor polls cond until it is true or a second passes.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	for start := time.Now(); !cond(); time.Sleep(5 * time.Millisecond) {
+		if time.Since(start) > time.Second {
+			t.Fatalf("Timeout waiting for %s", what)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filters := []Filter{
+		{},
+		{Types: []uint8{5}},
+		{Box: &BoundingBox{MinLon: 17, MinLat: 59, MaxLon: 19, MaxLat: 60}},
+		{MMSIs: []uint32{601041200}},
+	}
+	var mu sync.Mutex
+	var disconnected int
+	s := NewServer(RouterOptions{Archive: true})
+	s.Filter = func(remote net.Addr) Filter {
+		mu.Lock()
+		defer mu.Unlock()
+		f := filters[0]
+		filters = filters[1:]
+		return f
+	}
+	s.OnClient = func(remote net.Addr, connected bool, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !connected {
+			disconnected++
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Serve(ctx, ln) }()
+
+	var clients []*bufio.Reader
+	var conns []net.Conn
+	for i := 0; i < 4; i++ {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		waitFor(t, "client", func() bool { return s.Clients() == i+1 })
+		conns = append(conns, conn)
+		clients = append(clients, bufio.NewReader(conn))
+	}
+
+	moved, _ := EncodeClassBPositionReport(ClassBPositionReport{
+		PositionReport: PositionReport{Type: 18, MMSI: 205280890, Lon: 18.5, Lat: 59.5}})
+	lines := []string{
+		"1425772826 rx1 !AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F",
+		"\\g:1-2-73874,s:rx2,c:1425772830*42\\!AIVDM,2,1,5,A,533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H,0*44",
+		"\\g:2-2-73874*62\\!AIVDM,2,2,5,A,51CU0E2CkP0,2*0C",
+		testSentence("B3ujWF0000DdVU8O:1H03wi5oP06"),
+		testSentence(moved.Payload),
+	}
+	broadcast := append(lines, lines[1], lines[2], "!AIVDM,1,1,,B,bad*00")
+	for _, l := range broadcast {
+		s.Broadcast(l)
+	}
+
+	// The archive decoration is stripped
+	sent := []string{
+		"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F",
+		"!AIVDM,2,1,5,A,533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H,0*44",
+		"!AIVDM,2,2,5,A,51CU0E2CkP0,2*0C",
+		lines[3],
+		lines[4],
+	}
+	want := [][]string{
+		{sent[0], sent[1], sent[2], sent[3], sent[4], sent[1], sent[2]},
+		{sent[1], sent[2], sent[1], sent[2]},
+		{sent[3], sent[4], sent[1], sent[2]}, // Type 5 passes once the position is known
+		{sent[0]},
+	}
+	for i, c := range clients {
+		for _, w := range want[i] {
+			conns[i].SetReadDeadline(time.Now().Add(time.Second))
+			got, err := c.ReadString('\n')
+			if err != nil || got != w+"\r\n" {
+				t.Errorf("Server client %d got %q, %v, want %q", i, got, err, w)
+				break
+			}
+		}
+	}
+
+	conns[3].Close()
+	waitFor(t, "disconnection", func() bool { return s.Clients() == 3 })
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Server.Serve() = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Server.Serve() didn't stop when cancelled")
+	}
+	if s.Clients() != 0 || disconnected != 4 {
+		t.Errorf("Server has %d clients and reported %d disconnections, want 0 and 4", s.Clients(), disconnected)
+	}
+}
+
+// pipeListener is a net.Listener of in-memory connections, which block until read.
+type pipeListener struct {
+	conns  chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (l *pipeListener) dial() net.Conn {
+	client, server := net.Pipe()
+	l.conns <- server
+	return client
+}
+
+func (l *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *pipeListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *pipeListener) Addr() net.Addr { return &net.TCPAddr{} }
+
+func TestServerExpire(t *testing.T) {
+	ln := &pipeListener{conns: make(chan net.Conn), closed: make(chan struct{})}
+	s := NewServer(RouterOptions{Checksum: ChecksumTrim})
+	s.Filter = func(remote net.Addr) Filter {
+		return Filter{Box: &BoundingBox{MinLon: 17, MinLat: 59, MaxLon: 19, MaxLat: 60}}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Serve(ctx, ln)
+	conn := ln.dial()
+	defer conn.Close()
+	waitFor(t, "client", func() bool { return s.Clients() == 1 })
+	r := bufio.NewReader(conn)
+
+	// Trailing whitespace isn't relayed
+	position := testSentence("B3ujWF0000DdVU8O:1H03wi5oP06")
+	s.Broadcast(position + " \r")
+	if got, err := r.ReadString('\n'); err != nil || got != position+"\r\n" {
+		t.Errorf("Server client got %q, %v, want %q", got, err, position+"\r\n")
+	}
+
+	// Once its position is forgotten, the static data of a station don't match the box
+	partA, _ := EncodeStaticDataReport(StaticDataReport{MMSI: 266119000, VesselName: "SEA BREEZE"})
+	static := testSentence(partA.Payload)
+	if n := s.Expire(time.Now().Add(-time.Minute)); n != 0 {
+		t.Errorf("Server.Expire(before time.Time) = %d, want 0", n)
+	}
+	if n := s.Expire(time.Now().Add(time.Minute)); n != 1 {
+		t.Errorf("Server.Expire(before time.Time) = %d, want 1", n)
+	}
+	s.Broadcast(static)
+	s.Broadcast(position)
+	if got, err := r.ReadString('\n'); err != nil || got != position+"\r\n" {
+		t.Errorf("Server client got %q, %v, want %q", got, err, position+"\r\n")
+	}
+}
+
+func TestServerSlowClient(t *testing.T) {
+	ln := &pipeListener{conns: make(chan net.Conn), closed: make(chan struct{})}
+	s := NewServer(RouterOptions{})
+	s.ClientBuffer = 2
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Serve(ctx, ln)
+
+	slow := ln.dial() // Never reads
+	defer slow.Close()
+	fast := ln.dial()
+	defer fast.Close()
+	waitFor(t, "clients", func() bool { return s.Clients() == 2 })
+	received := make(chan string, 16)
+	go func() {
+		r := bufio.NewReader(fast)
+		for {
+			l, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			received <- l
+		}
+	}()
+
+	// The first message blocks the writer of the slow client, two more fill its queue
+	line := testSentence("38u<a<?PAA2>P:WfuAO9PW<P0PuQ")
+	for i := 0; i < 3; i++ {
+		s.Broadcast(line)
+		<-received
+	}
+	if s.Clients() != 2 {
+		t.Fatalf("Server dropped a client too early")
+	}
+	s.Broadcast(line) // Doesn't block
+	<-received
+	if s.Clients() != 1 || s.Dropped() != 1 {
+		t.Errorf("Server has %d clients and dropped %d, want 1 and 1", s.Clients(), s.Dropped())
+	}
+}
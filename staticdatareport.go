@@ -44,42 +44,86 @@ type StaticDataReport struct {
 
 // DecodeStaticDataReport decodes the payload of a Type 24 AIS message
 func DecodeStaticDataReport(payload string) (StaticDataReport, error) {
-	data := []byte(payload)
+	r := NewBitReader(payload)
 	var m StaticDataReport
 
-	mType := decodeAisChar(data[0])
+	mType := uint8(r.Uint(0, 5))
 	if mType != 24 {
 		return m, errors.New("Message isn't Static Data Station Report (type 24).")
 	}
 
-	m.Repeat = uint8(bitsToInt(6, 7, data))
+	m.Repeat = uint8(r.Uint(6, 7))
 
 	//m.MMSI = uint32(decodeAisChar(data[1]))<<28>>2 | uint32(decodeAisChar(data[2]))<<20 |
 	//	uint32(decodeAisChar(data[3]))<<14 | uint32(decodeAisChar(data[4]))<<8 |
 	//	uint32(decodeAisChar(data[5]))<<2 | uint32(decodeAisChar(data[6]))>>4
-	m.MMSI = bitsToInt(8, 37, data)
+	m.MMSI = uint32(r.Uint(8, 37))
 
-	m.PartNo = uint8(bitsToInt(38, 39, data))
+	m.PartNo = uint8(r.Uint(38, 39))
 	if m.PartNo == 0 {
-		m.VesselName = bitsToString(112, 231, data)
+		m.VesselName = r.String(40, 159)
 	} else {
-		m.ShipType = uint8(bitsToInt(40, 47, data))
-		m.VendorID = bitsToString(48, 65, data)
+		m.ShipType = uint8(r.Uint(40, 47))
+		m.VendorID = r.String(48, 65)
 
-		m.UnitModelCode = uint8(bitsToInt(66, 69, data))
-		m.SerialNumber = uint32(bitsToInt(70, 89, data))
-		m.CallSign = bitsToString(90, 131, data)
+		m.UnitModelCode = uint8(r.Uint(66, 69))
+		m.SerialNumber = uint32(r.Uint(70, 89))
+		m.CallSign = r.String(90, 131)
 
 		// its an auxiliary craft
 		if m.MMSI >= 980000000 {
-			m.MothershipMMSI = bitsToInt(132, 161, data)
+			m.MothershipMMSI = uint32(r.Uint(132, 161))
 		} else {
-			m.ToBow = uint16(bitsToInt(132, 140, data))
-			m.ToStern = uint16(bitsToInt(141, 149, data))
-			m.ToPort = uint8(bitsToInt(150, 155, data))
-			m.ToStarboard = uint8(bitsToInt(156, 161, data))
+			m.ToBow = uint16(r.Uint(132, 140))
+			m.ToStern = uint16(r.Uint(141, 149))
+			m.ToPort = uint8(r.Uint(150, 155))
+			m.ToStarboard = uint8(r.Uint(156, 161))
 		}
 	}
 
 	return m, nil
 }
+
+// EncodeStaticDataReport encodes a Static Data Report (type 24) to an AIS message, the inverse
+// of DecodeStaticDataReport. PartNo selects the part written: 0 for part A (vessel name), 1 for
+// part B.
+func EncodeStaticDataReport(m StaticDataReport) (Message, error) {
+	if m.PartNo > 1 {
+		return Message{}, errors.New("Static Data Report part number should be 0 (A) or 1 (B).")
+	}
+	w := NewBitWriter(168)
+	if m.PartNo == 0 {
+		w = NewBitWriter(160)
+	}
+
+	w.Uint(0, 5, 24)
+	w.Uint(6, 7, uint64(m.Repeat))
+	w.Uint(8, 37, uint64(m.MMSI))
+	w.Uint(38, 39, uint64(m.PartNo))
+	if m.PartNo == 0 {
+		if err := w.String(40, 159, m.VesselName); err != nil {
+			return Message{}, err
+		}
+	} else {
+		w.Uint(40, 47, uint64(m.ShipType))
+		if err := w.String(48, 65, m.VendorID); err != nil {
+			return Message{}, err
+		}
+		w.Uint(66, 69, uint64(m.UnitModelCode))
+		w.Uint(70, 89, uint64(m.SerialNumber))
+		if err := w.String(90, 131, m.CallSign); err != nil {
+			return Message{}, err
+		}
+		if m.MMSI >= 980000000 {
+			w.Uint(132, 161, uint64(m.MothershipMMSI))
+		} else {
+			w.Uint(132, 140, uint64(m.ToBow))
+			w.Uint(141, 149, uint64(m.ToStern))
+			w.Uint(150, 155, uint64(m.ToPort))
+			w.Uint(156, 161, uint64(m.ToStarboard))
+		}
+	}
+
+	payload, padding := w.Payload()
+	return Message{Type: 24, Payload: payload, Padding: padding}, nil
+}
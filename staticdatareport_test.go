@@ -0,0 +1,90 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDecodeStaticDataReport(t *testing.T) {
+	cases := []struct {
+		payload string
+		want    StaticDataReport
+	}{
+		{
+			"H42O55i18tMET00000000000000",
+			StaticDataReport{Repeat: 0, MMSI: 271041815, PartNo: 0, VesselName: "PROGUY"},
+		},
+		{
+			"H42O55lti4hhhilD3nink000?050",
+			StaticDataReport{Repeat: 0, MMSI: 271041815, PartNo: 1, ShipType: 60, VendorID: "1D0",
+				UnitModelCode: 12, SerialNumber: 199796, CallSign: "TC6163", ToBow: 0, ToStern: 15,
+				ToPort: 0, ToStarboard: 5},
+		},
+		{
+			"H3mr@L4NC=D62?P<7nmpl00@8220",
+			StaticDataReport{Repeat: 0, MMSI: 257855600, PartNo: 1, ShipType: 30, VendorID: "SMT",
+				UnitModelCode: 1, SerialNumber: 533472, CallSign: "LG6584", ToBow: 2, ToStern: 8,
+				ToPort: 2, ToStarboard: 2},
+		},
+	}
+	for _, c := range cases {
+		got, _ := DecodeStaticDataReport(c.payload)
+		if got != c.want {
+			fmt.Println("Got : ", got)
+			fmt.Println("Want: ", c.want)
+			t.Errorf("DecodeStaticDataReport(payload string)")
+		}
+	}
+}
+
+func TestEncodeStaticDataReport(t *testing.T) {
+	cases := []struct {
+		payload string
+		padding uint8
+	}{
+		{"H42O55i18tMET00000000000000", 2},
+		{"H42O55lti4hhhilD3nink000?050", 0},
+		{"H3mr@L4NC=D62?P<7nmpl00@8220", 0},
+	}
+	for _, c := range cases {
+		m, _ := DecodeStaticDataReport(c.payload)
+		got, err := EncodeStaticDataReport(m)
+		if err != nil || got != (Message{Type: 24, Payload: c.payload, Padding: c.padding}) {
+			fmt.Println("Got : ", got, err)
+			fmt.Println("Want: ", c.payload, c.padding)
+			t.Errorf("EncodeStaticDataReport(m StaticDataReport)")
+		}
+	}
+
+	// Auxiliary craft
+	want := StaticDataReport{Repeat: 0, MMSI: 982710418, PartNo: 1, ShipType: 31, VendorID: "ABC",
+		UnitModelCode: 3, SerialNumber: 1000, CallSign: "XYZ", MothershipMMSI: 271041815}
+	e, err := EncodeStaticDataReport(want)
+	got, _ := DecodeStaticDataReport(e.Payload)
+	if err != nil || got != want {
+		fmt.Println("Got : ", got, err)
+		fmt.Println("Want: ", want)
+		t.Errorf("EncodeStaticDataReport(m StaticDataReport)")
+	}
+
+	if _, err := EncodeStaticDataReport(StaticDataReport{PartNo: 2}); err == nil {
+		t.Errorf("EncodeStaticDataReport(m StaticDataReport) accepted part number 2")
+	}
+}
@@ -46,52 +46,85 @@ type StaticVoyageData struct {
 
 // DecodeStaticVoyageData decodes [the payload of] an AIS Static and Voyage Related Data message (type 5)
 func DecodeStaticVoyageData(payload string) (StaticVoyageData, error) {
-	data := []byte(payload)
+	r := NewBitReader(payload)
 	var m StaticVoyageData
 
-	mType := decodeAisChar(data[0])
+	mType := uint8(r.Uint(0, 5))
 	if mType != 5 {
 		return m, errors.New("Message isn't Static and Voyage Related Data (type 5).")
 	}
-	m.Repeat = uint8(bitsToInt(6, 7, data))
+	m.Repeat = uint8(r.Uint(6, 7))
 
-	m.MMSI = uint32(bitsToInt(8, 37, data))
+	m.MMSI = uint32(r.Uint(8, 37))
 
-	m.AisVersion = uint8(bitsToInt(38, 39, data))
+	m.AisVersion = uint8(r.Uint(38, 39))
 
-	m.IMO = uint32(bitsToInt(40, 69, data))
+	m.IMO = uint32(r.Uint(40, 69))
 
-	m.Callsign = bitsToString(70, 111, data)
+	m.Callsign = r.String(70, 111)
 
-	m.VesselName = bitsToString(112, 231, data)
+	m.VesselName = r.String(112, 231)
 
-	m.ShipType = uint8(bitsToInt(232, 239, data))
+	m.ShipType = uint8(r.Uint(232, 239))
 
-	m.ToBow = uint16(bitsToInt(240, 248, data))
-	m.ToStern = uint16(bitsToInt(249, 257, data))
-	m.ToPort = uint8(bitsToInt(258, 263, data))
-	m.ToStarboard = uint8(bitsToInt(264, 269, data))
+	m.ToBow = uint16(r.Uint(240, 248))
+	m.ToStern = uint16(r.Uint(249, 257))
+	m.ToPort = uint8(r.Uint(258, 263))
+	m.ToStarboard = uint8(r.Uint(264, 269))
 
-	m.EPFD = uint8(bitsToInt(270, 273, data))
+	m.EPFD = uint8(r.Uint(270, 273))
 
 	// ETA does not include year, so we omit it too (it is set as 0000)
 	// cyear := time.Now().Year()
-	month := uint8(bitsToInt(274, 277, data))
-	day := uint8(bitsToInt(278, 282, data))
-	hour := uint8(bitsToInt(283, 287, data))
-	minute := uint8(bitsToInt(288, 293, data))
+	month := uint8(r.Uint(274, 277))
+	day := uint8(r.Uint(278, 282))
+	hour := uint8(r.Uint(283, 287))
+	minute := uint8(r.Uint(288, 293))
 	timeString := fmt.Sprintf("%d/%d %d:%d", month, day, hour, minute)
 	m.ETA, _ = time.Parse("1/2 15:4", timeString)
 
-	m.Draught = uint8(bitsToInt(294, 301, data))
+	m.Draught = uint8(r.Uint(294, 301))
 
-	m.Destination = bitsToString(302, 421, data)
+	m.Destination = r.String(302, 421)
 
-	m.DTE = cbnBool(422, data)
+	m.DTE = r.Bool(422)
 
 	return m, nil
 }
 
+// EncodeStaticVoyageData encodes Static and Voyage Related Data (type 5) to an AIS message, the
+// inverse of DecodeStaticVoyageData. A zero ETA is written as not available.
+func EncodeStaticVoyageData(m StaticVoyageData) (Message, error) {
+	w := NewBitWriter(424)
+
+	w.Uint(0, 5, 5)
+	w.Uint(6, 7, uint64(m.Repeat))
+	w.Uint(8, 37, uint64(m.MMSI))
+	w.Uint(38, 39, uint64(m.AisVersion))
+	w.Uint(40, 69, uint64(m.IMO))
+	if err := w.String(70, 111, m.Callsign); err != nil {
+		return Message{}, err
+	}
+	if err := w.String(112, 231, m.VesselName); err != nil {
+		return Message{}, err
+	}
+	w.Uint(232, 239, uint64(m.ShipType))
+	w.Uint(240, 248, uint64(m.ToBow))
+	w.Uint(249, 257, uint64(m.ToStern))
+	w.Uint(258, 263, uint64(m.ToPort))
+	w.Uint(264, 269, uint64(m.ToStarboard))
+	w.Uint(270, 273, uint64(m.EPFD))
+	cbnPutMonthDayTime(274, w, m.ETA)
+	w.Uint(294, 301, uint64(m.Draught))
+	if err := w.String(302, 421, m.Destination); err != nil {
+		return Message{}, err
+	}
+	w.Bool(422, m.DTE)
+
+	payload, padding := w.Payload()
+	return Message{Type: 5, Payload: payload, Padding: padding}, nil
+}
+
 // Ship types codes.
 var ShipType = map[int]string{
 	0:  "Not available",
@@ -57,6 +57,38 @@ func TestDecodeStaticVoyageData(t *testing.T) {
 	}
 }
 
+func TestEncodeStaticVoyageData(t *testing.T) {
+	eta, _ := time.Parse("1/2 15:4", "12/31 23:59")
+	cases := []StaticVoyageData{
+		{
+			Repeat: 0, MMSI: 265731560, AisVersion: 0, IMO: 8026361, Callsign: "SBTI",
+			VesselName: "TOFTE", ShipType: 52, ToBow: 7, ToStern: 17, ToPort: 4, ToStarboard: 4,
+			EPFD: 1, ETA: eta, Draught: 40, Destination: "GOTEBORG", DTE: false,
+		},
+		{ // Not available ETA and all the characters of six bit ASCII
+			Repeat: 2, MMSI: 257556700, AisVersion: 1, IMO: 1073741823, Callsign: "AZ[\\]^_",
+			VesselName: " !\"#$%&'()*+,-./0123", ShipType: 255, ToBow: 511, ToStern: 511, ToPort: 63,
+			ToStarboard: 63, EPFD: 15, Draught: 255, Destination: "456789:;<=>?", DTE: true,
+		},
+	}
+	for _, want := range cases {
+		e, err := EncodeStaticVoyageData(want)
+		got, _ := DecodeStaticVoyageData(e.Payload)
+		if err != nil || got != want || e.Type != 5 || len(e.Payload) != 71 || e.Padding != 2 {
+			fmt.Println("Got : ", got, e, err)
+			fmt.Println("Want: ", want)
+			t.Errorf("EncodeStaticVoyageData(m StaticVoyageData)")
+		}
+	}
+
+	invalid := []StaticVoyageData{{Callsign: "SBTI1234"}, {VesselName: "TOFTE`"}, {Destination: "GÖTEBORG"}}
+	for _, m := range invalid {
+		if _, err := EncodeStaticVoyageData(m); err == nil {
+			t.Errorf("EncodeStaticVoyageData(m StaticVoyageData) accepted %v", m)
+		}
+	}
+}
+
 func BenchmarkDecodeStaticVoyageData(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		DecodeStaticVoyageData("53uJur01rN?U<9@T001@tI@F000000000000000l0pA444mm?:1km1@SlQp000000000000")
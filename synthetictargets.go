@@ -0,0 +1,107 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"errors"
+)
+
+// Target identifier types of VTS synthetic targets
+const (
+	TargetIDMMSI = iota
+	TargetIDIMO
+	TargetIDCallsign
+	TargetIDOther
+)
+
+// SyntheticTarget is a target tracked by a VTS centre (e.g by radar).
+type SyntheticTarget struct {
+	IDType uint8   // enumerated, TargetIDMMSI, TargetIDIMO, TargetIDCallsign or TargetIDOther
+	ID     uint32  // MMSI or IMO number, depending on IDType
+	Name   string  // Callsign or other identification, depending on IDType
+	Lon    float64 // Low resolution (1/1000 minutes)
+	Lat    float64
+	Course uint16 // course over ground - COG in degrees, 360: not available
+	Second uint8  // timestamp
+	Speed  uint8  // speed over ground - SOG in knots, 255: not available
+}
+
+// VTSTargets is a DAC 1, FID 17 message. It carries up to four synthetic targets.
+type VTSTargets struct {
+	BinaryHeader
+	Targets []SyntheticTarget
+}
+
+// DecodeVTSTargets decodes the payload of a VTS-Generated/Synthetic Targets message (type 8 DAC 1 FID 17).
+func DecodeVTSTargets(payload string) (VTSTargets, error) {
+	r := NewBitReader(payload)
+	var m VTSTargets
+
+	m.BinaryHeader, _ = decodeBinaryHeader(r)
+	if m.Type != 8 || m.DAC != 1 || m.FID != 17 {
+		return m, errors.New("Message isn't VTS Synthetic Targets (type 8, DAC 1, FID 17).")
+	}
+
+	// Each target takes 120 bits
+	for first := 56; first+120 <= r.Len() && len(m.Targets) < 4; first += 120 {
+		var t SyntheticTarget
+
+		t.IDType = uint8(r.Uint(first, first+1))
+		if t.IDType == TargetIDMMSI || t.IDType == TargetIDIMO {
+			t.ID = uint32(r.Uint(first+14, first+43)) // Numbers use the rightmost 30 bits of the ID field
+		} else {
+			t.Name = r.String(first+2, first+43)
+		}
+
+		// Latitude comes first in this message
+		lat := float64(r.Int(first+48, first+71)) * 10
+		lon := float64(r.Int(first+72, first+96)) * 10
+		t.Lon, t.Lat = CoordinatesMin2Deg(lon, lat)
+
+		t.Course = uint16(r.Uint(first+97, first+105))
+		t.Second = uint8(r.Uint(first+106, first+111))
+		t.Speed = uint8(r.Uint(first+112, first+119))
+
+		m.Targets = append(m.Targets, t)
+	}
+
+	return m, nil
+}
+
+// PositionReport returns the target as a position report, so it can be handled along with the
+// position reports of AIS equipped vessels. Type is left at 0, as the report doesn't come from
+// an AIS message, and MMSI is set only for targets identified by MMSI.
+func (t SyntheticTarget) PositionReport() PositionReport {
+	var p PositionReport
+
+	if t.IDType == TargetIDMMSI {
+		p.MMSI = t.ID
+	}
+
+	p.Speed = float32(t.Speed)
+	if t.Speed == 255 {
+		p.Speed = 1023
+	}
+
+	p.Lon, p.Lat = t.Lon, t.Lat
+	p.Course = float32(t.Course)
+	p.Heading = 511 // not available
+	p.Second = t.Second
+
+	return p
+}
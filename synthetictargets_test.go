@@ -0,0 +1,64 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeVTSTargets(t *testing.T) {
+	want := VTSTargets{
+		BinaryHeader: BinaryHeader{Type: 8, Repeat: 0, MMSI: 2442000, DAC: 1, FID: 17},
+		Targets: []SyntheticTarget{
+			{IDType: TargetIDMMSI, ID: 244670316, Lon: 4.5667, Lat: 52.4639, Course: 271, Second: 33, Speed: 12},
+			{IDType: TargetIDCallsign, Name: "PD3456", Lon: 4.55, Lat: 52.47, Course: 360, Second: 60, Speed: 255},
+		},
+	}
+	got, _ := DecodeVTSTargets("802E3400D@00rEGnh308>P8G:D?Q390C?CGH0309b08E=5`twh")
+	if !reflect.DeepEqual(got, want) {
+		fmt.Println("Got : ", got)
+		fmt.Println("Want: ", want)
+		t.Errorf("DecodeVTSTargets(payload string)")
+	}
+}
+
+func TestSyntheticTargetPositionReport(t *testing.T) {
+	cases := []struct {
+		target SyntheticTarget
+		want   PositionReport
+	}{
+		{
+			SyntheticTarget{IDType: TargetIDMMSI, ID: 244670316, Lon: 4.5667, Lat: 52.4639, Course: 271, Second: 33, Speed: 12},
+			PositionReport{MMSI: 244670316, Speed: 12, Lon: 4.5667, Lat: 52.4639, Course: 271, Heading: 511, Second: 33},
+		},
+		{
+			SyntheticTarget{IDType: TargetIDCallsign, Name: "PD3456", Lon: 4.55, Lat: 52.47, Course: 360, Second: 60, Speed: 255},
+			PositionReport{MMSI: 0, Speed: 1023, Lon: 4.55, Lat: 52.47, Course: 360, Heading: 511, Second: 60},
+		},
+	}
+	for _, c := range cases {
+		got := c.target.PositionReport()
+		if got != c.want {
+			fmt.Println("Got : ", got)
+			fmt.Println("Want: ", c.want)
+			t.Errorf("SyntheticTarget.PositionReport()")
+		}
+	}
+}
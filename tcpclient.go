@@ -0,0 +1,287 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SourceState is the state of the connection of an input source.
+type SourceState int
+
+// Source states.
+const (
+	StateConnecting   SourceState = iota // Trying to connect or open the device
+	StateConnected                       // Receiving data
+	StateDisconnected                    // Connection lost or failed, waiting to retry
+	StateStopped                         // Stopped by its context
+)
+
+var sourceStateNames = [...]string{"connecting", "connected", "disconnected", "stopped"}
+
+func (s SourceState) String() string {
+	if s < 0 || int(s) >= len(sourceStateNames) {
+		return "unknown"
+	}
+	return sourceStateNames[s]
+}
+
+// SourceStats are the counters of an input source.
+type SourceStats struct {
+	Connects    int64 // Successful connections
+	Failures    int64 // Failed connection attempts and lost connections
+	Lines       int64 // Lines delivered
+	Bytes       int64 // Bytes read
+	LongLines   int64 // Lines dropped for being longer than the limit
+	LastData    time.Time
+	LastFailure time.Time
+}
+
+// sourceCounters keeps SourceStats safe for concurrent use.
+type sourceCounters struct {
+	mu    sync.Mutex
+	stats SourceStats
+}
+
+func (c *sourceCounters) update(f func(s *SourceStats)) {
+	c.mu.Lock()
+	f(&c.stats)
+	c.mu.Unlock()
+}
+
+func (c *sourceCounters) get() SourceStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// Defaults of TCPClient.
+const (
+	DefaultMinBackoff  = time.Second
+	DefaultMaxBackoff  = 2 * time.Minute
+	DefaultReadTimeout = time.Minute
+	DefaultDialTimeout = 30 * time.Second
+)
+
+// A TCPClient connects to a TCP server that sends NMEA sentences (an AIS receiver or a feed
+// like AISHub) and delivers them line by line, reconnecting whenever the connection fails.
+// Reconnections wait with exponential backoff, from MinBackoff up to MaxBackoff, which resets
+// when a connection delivers data. A connection that delivers nothing for ReadTimeout is
+// considered lost.
+//
+// Set the fields before calling Run. Zero durations take the defaults.
+type TCPClient struct {
+	Address     string
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	ReadTimeout time.Duration
+	DialTimeout time.Duration
+
+	// MaxLineLength, if set, overrides DefaultMaxLineLength.
+	MaxLineLength int
+
+	// OnStateChange, if set, is called on every state change with the error that caused it,
+	// if any. It is called from the goroutine of Run, so it should return quickly.
+	OnStateChange func(address string, state SourceState, err error)
+
+	counters sourceCounters
+	running  int32
+}
+
+// NewTCPClient returns a TCPClient for address (host:port) with the default settings.
+func NewTCPClient(address string) *TCPClient {
+	return &TCPClient{Address: address}
+}
+
+// Stats returns the counters of the client. It is safe to call while Run is running.
+func (c *TCPClient) Stats() SourceStats {
+	return c.counters.get()
+}
+
+func (c *TCPClient) setState(state SourceState, err error) {
+	if c.OnStateChange != nil {
+		c.OnStateChange(c.Address, state, err)
+	}
+}
+
+// Run connects to the server and sends the lines it receives to out (e.g the in channel of a
+// Router), until ctx is cancelled. Then it returns the context's error. A client can run
+// only once at a time.
+func (c *TCPClient) Run(ctx context.Context, out chan<- string) error {
+	if !atomic.CompareAndSwapInt32(&c.running, 0, 1) {
+		return errors.New("TCPClient is already running.")
+	}
+	defer atomic.StoreInt32(&c.running, 0)
+
+	return runSource(ctx, c.MinBackoff, c.MaxBackoff, &c.counters, c.setState, func() (bool, error) {
+		return c.connect(ctx, out)
+	})
+}
+
+// runSource runs connect until ctx is cancelled, waiting between the failed connections with
+// exponential backoff from min up to max. The backoff resets when connect reports that it
+// received data.
+func runSource(ctx context.Context, min, max time.Duration, counters *sourceCounters,
+	setState func(SourceState, error), connect func() (bool, error)) error {
+	if min <= 0 {
+		min = DefaultMinBackoff
+	}
+	if max < min {
+		max = DefaultMaxBackoff
+		if max < min {
+			max = min
+		}
+	}
+
+	backoff := min
+	for {
+		setState(StateConnecting, nil)
+		received, err := connect()
+		if ctx.Err() != nil {
+			setState(StateStopped, nil)
+			return ctx.Err()
+		}
+
+		counters.update(func(s *SourceStats) {
+			s.Failures++
+			s.LastFailure = time.Now()
+		})
+		if received {
+			backoff = min
+		}
+		setState(StateDisconnected, err)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			setState(StateStopped, nil)
+			return ctx.Err()
+		}
+		if backoff *= 2; backoff > max {
+			backoff = max
+		}
+	}
+}
+
+// connect makes one connection and reads it until it fails. It reports if any data were
+// received, so Run can reset the backoff.
+func (c *TCPClient) connect(ctx context.Context, out chan<- string) (bool, error) {
+	timeout := c.DialTimeout
+	if timeout <= 0 {
+		timeout = DefaultDialTimeout
+	}
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.Address)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	c.counters.update(func(s *SourceStats) { s.Connects++ })
+	c.setState(StateConnected, nil)
+
+	// Unblock reads when the context is cancelled
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-stop:
+		}
+	}()
+
+	readTimeout := c.ReadTimeout
+	if readTimeout <= 0 {
+		readTimeout = DefaultReadTimeout
+	}
+	return readLines(ctx, &deadlineReader{conn, readTimeout, &c.counters}, c.MaxLineLength, &c.counters, out)
+}
+
+// deadlineConn is a connection or device with read deadlines.
+type deadlineConn interface {
+	io.Reader
+	SetReadDeadline(t time.Time) error
+}
+
+// deadlineReader extends the read deadline of a connection before every read, unless timeout
+// is zero, and counts the bytes read.
+type deadlineReader struct {
+	conn     deadlineConn
+	timeout  time.Duration
+	counters *sourceCounters
+}
+
+func (r *deadlineReader) Read(p []byte) (int, error) {
+	if r.timeout > 0 {
+		r.conn.SetReadDeadline(time.Now().Add(r.timeout))
+	}
+	n, err := r.conn.Read(p)
+	if n > 0 {
+		r.counters.update(func(s *SourceStats) {
+			s.Bytes += int64(n)
+			s.LastData = time.Now()
+		})
+	}
+	return n, err
+}
+
+// readLines reads lines from r and sends them to out until r fails or ctx is cancelled. Lines
+// longer than max (DefaultMaxLineLength if max isn't set) are dropped. It reports if any data
+// were read.
+func readLines(ctx context.Context, r io.Reader, max int, counters *sourceCounters, out chan<- string) (bool, error) {
+	if max <= 0 {
+		max = DefaultMaxLineLength
+	}
+	br := bufio.NewReaderSize(r, max)
+	received := false
+	for {
+		data, isPrefix, err := br.ReadLine()
+		if err != nil {
+			return received, err
+		}
+		received = true
+		if isPrefix { // Skip the rest of the line
+			for isPrefix && err == nil {
+				_, isPrefix, err = br.ReadLine()
+			}
+			counters.update(func(s *SourceStats) { s.LongLines++ })
+			if err != nil {
+				return received, err
+			}
+			continue
+		}
+		if len(data) == 0 {
+			continue
+		}
+
+		select {
+		case out <- string(data):
+			counters.update(func(s *SourceStats) { s.Lines++ })
+		case <-ctx.Done():
+			return received, ctx.Err()
+		}
+	}
+}
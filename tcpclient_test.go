@@ -0,0 +1,201 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stateRecorder collects the state changes of a source.
+type stateRecorder struct {
+	mu     sync.Mutex
+	states []SourceState
+	errs   []error
+	times  []time.Time
+}
+
+func (r *stateRecorder) record(address string, state SourceState, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states = append(r.states, state)
+	r.errs = append(r.errs, err)
+	r.times = append(r.times, time.Now())
+}
+
+func (r *stateRecorder) get() ([]SourceState, []error, []time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]SourceState(nil), r.states...), append([]error(nil), r.errs...), append([]time.Time(nil), r.times...)
+}
+
+func TestTCPClient(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	// The first connection sends two lines and breaks, the second one sends a line and stays
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		conn.Write([]byte("!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F\r\n\r\nline 2\n"))
+		conn.Close()
+
+		conn, err = ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("line 3\n"))
+		time.Sleep(time.Second)
+	}()
+
+	var rec stateRecorder
+	c := NewTCPClient(ln.Addr().String())
+	c.MinBackoff = 10 * time.Millisecond
+	c.OnStateChange = rec.record
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan string)
+	done := make(chan error)
+	go func() { done <- c.Run(ctx, out) }()
+
+	for _, want := range []string{"!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F", "line 2", "line 3"} {
+		select {
+		case got := <-out:
+			if got != want {
+				t.Errorf("TCPClient.Run() sent %q, want %q", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("TCPClient.Run() didn't send %q", want)
+		}
+	}
+
+	if err := c.Run(ctx, out); err == nil {
+		t.Errorf("TCPClient.Run() ran twice at the same time")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("TCPClient.Run() = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("TCPClient.Run() didn't stop when cancelled")
+	}
+
+	states, _, _ := rec.get()
+	want := []SourceState{StateConnecting, StateConnected, StateDisconnected, StateConnecting, StateConnected, StateStopped}
+	if len(states) != len(want) {
+		t.Fatalf("TCPClient states %v, want %v", states, want)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Errorf("TCPClient states %v, want %v", states, want)
+			break
+		}
+	}
+
+	stats := c.Stats()
+	if stats.Connects != 2 || stats.Failures != 1 || stats.Lines != 3 || stats.Bytes != 65 {
+		t.Errorf("TCPClient.Stats() = %+v", stats)
+	}
+}
+
+func TestTCPClientBackoff(t *testing.T) {
+	// Nobody listens at the address of a closed listener
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := ln.Addr().String()
+	ln.Close()
+
+	var rec stateRecorder
+	c := NewTCPClient(address)
+	c.MinBackoff = 20 * time.Millisecond
+	c.MaxBackoff = 40 * time.Millisecond
+	c.OnStateChange = rec.record
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	c.Run(ctx, make(chan string))
+
+	var attempts []time.Time
+	states, errs, times := rec.get()
+	for i, s := range states {
+		if s == StateConnecting {
+			attempts = append(attempts, times[i])
+		}
+		if s == StateDisconnected && errs[i] == nil {
+			t.Errorf("TCPClient reported disconnection without error")
+		}
+	}
+	if len(attempts) < 4 {
+		t.Fatalf("TCPClient made %d connection attempts, want at least 4", len(attempts))
+	}
+	first, second, third := attempts[1].Sub(attempts[0]), attempts[2].Sub(attempts[1]), attempts[3].Sub(attempts[2])
+	if first < 20*time.Millisecond || second < 40*time.Millisecond || third < 40*time.Millisecond || third > 80*time.Millisecond {
+		t.Errorf("TCPClient waited %v, %v, %v between attempts, want 20ms, 40ms, 40ms", first, second, third)
+	}
+	if states[len(states)-1] != StateStopped {
+		t.Errorf("TCPClient last state %v, want stopped", states[len(states)-1])
+	}
+}
+
+func TestTCPClientReadTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close() // Silent connection
+		}
+	}()
+
+	var rec stateRecorder
+	c := NewTCPClient(ln.Addr().String())
+	c.ReadTimeout = 30 * time.Millisecond
+	c.OnStateChange = rec.record
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	c.Run(ctx, make(chan string))
+
+	states, errs, _ := rec.get()
+	if len(states) < 3 || states[2] != StateDisconnected {
+		t.Fatalf("TCPClient states %v, want disconnection", states)
+	}
+	if e, ok := errs[2].(net.Error); !ok || !e.Timeout() {
+		t.Errorf("TCPClient disconnected with %v, want timeout", errs[2])
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"errors"
+)
+
+// TextDescription is a DAC 1, FID 29 (broadcast) or FID 30 (addressed) message. Its text
+// describes the area notice, route information or other message that carries the same
+// linkage ID and comes from the same station.
+type TextDescription struct {
+	BinaryHeader
+	LinkageID uint16 // Message linkage ID
+	Text      string
+}
+
+// TextTelegram is a DAC 1, FID 0 message. It can be either broadcast or addressed.
+type TextTelegram struct {
+	BinaryHeader
+	AckRequired bool
+	Sequence    uint16 // Text sequence number
+	Text        string
+}
+
+// DecodeTextDescription decodes the payload of a Text Description message (type 8 DAC 1 FID 29
+// or type 6 DAC 1 FID 30).
+func DecodeTextDescription(payload string) (TextDescription, error) {
+	r := NewBitReader(payload)
+	var m TextDescription
+	var start int
+
+	m.BinaryHeader, start = decodeBinaryHeader(r)
+	if m.DAC != 1 || !(m.Type == 8 && m.FID == 29 || m.Type == 6 && m.FID == 30) {
+		return m, errors.New("Message isn't Text Description (DAC 1, FID 29 or 30).")
+	}
+
+	m.LinkageID = uint16(r.Uint(start, start+9))
+
+	// Text occupies the rest of the message
+	m.Text = r.String(start+10, r.Len()-1)
+
+	return m, nil
+}
+
+// DecodeTextTelegram decodes the payload of a Text Telegram message (type 6 or 8, DAC 1 FID 0).
+func DecodeTextTelegram(payload string) (TextTelegram, error) {
+	r := NewBitReader(payload)
+	var m TextTelegram
+	var start int
+
+	m.BinaryHeader, start = decodeBinaryHeader(r)
+	if (m.Type != 6 && m.Type != 8) || m.DAC != 1 || m.FID != 0 {
+		return m, errors.New("Message isn't Text Telegram (DAC 1, FID 0).")
+	}
+
+	m.AckRequired = r.Bool(start)
+	m.Sequence = uint16(r.Uint(start+1, start+11))
+
+	m.Text = r.String(start+12, r.Len()-1)
+
+	return m, nil
+}
+
+// MessageLinkageID returns the message linkage ID of the DAC 1 messages that carry one:
+// area notices (FID 22, 23), extended ship static and voyage data (FID 24), route information
+// (FID 27, 28) and text descriptions (FID 29, 30).
+// Together with the MMSI of the sender it identifies which text description belongs to a message.
+func MessageLinkageID(payload string) (uint16, error) {
+	r := NewBitReader(payload)
+
+	h, start := decodeBinaryHeader(r)
+	if (h.Type != 6 && h.Type != 8) || h.DAC != 1 {
+		return 0, errors.New("Message isn't a DAC 1 binary message.")
+	}
+	switch h.FID {
+	case 22, 23, 24, 27, 28, 29, 30:
+		return uint16(r.Uint(start, start+9)), nil
+	}
+	return 0, errors.New("Message doesn't carry a linkage ID.")
+}
+
+// textLink identifies a text description: linkage IDs are unique only per sending station.
+type textLink struct {
+	MMSI      uint32
+	LinkageID uint16
+}
+
+// TextLinker stores text descriptions, so they can be associated with the area notice or
+// route information messages that reference them. It isn't safe for concurrent use.
+type TextLinker struct {
+	texts map[textLink]TextDescription
+}
+
+// NewTextLinker returns an empty TextLinker.
+func NewTextLinker() *TextLinker {
+	return &TextLinker{texts: make(map[textLink]TextDescription)}
+}
+
+// Add stores a text description. A newer description with the same linkage ID from the
+// same station replaces the older one.
+func (l *TextLinker) Add(t TextDescription) {
+	l.texts[textLink{t.MMSI, t.LinkageID}] = t
+}
+
+// Lookup returns the text description sent by a station with a certain linkage ID.
+func (l *TextLinker) Lookup(mmsi uint32, linkageID uint16) (TextDescription, bool) {
+	t, ok := l.texts[textLink{mmsi, linkageID}]
+	return t, ok
+}
+
+// Describe returns the text description linked to a binary message payload, if any.
+func (l *TextLinker) Describe(payload string) (TextDescription, bool) {
+	linkageID, err := MessageLinkageID(payload)
+	if err != nil {
+		return TextDescription{}, false
+	}
+	return l.Lookup(uint32(NewBitReader(payload).Uint(8, 37)), linkageID)
+}
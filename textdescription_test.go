@@ -0,0 +1,89 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDecodeTextDescription(t *testing.T) {
+	cases := []struct {
+		payload string
+		want    TextDescription
+	}{
+		{
+			"85Mwp`00GH181B2?EBP3<?C54P4E5PD?P4B5479>7dPEC5P>?BD8P381>>5<",
+			TextDescription{
+				BinaryHeader: BinaryHeader{Type: 8, Repeat: 0, MMSI: 366999712, DAC: 1, FID: 29},
+				LinkageID:    513, Text: "HARBOUR CLOSED DUE TO DREDGING, USE NORTH CHANNEL"},
+		},
+		{
+			"602R3KlwLamP05pC@QDU28<L`0DljAkQA0",
+			TextDescription{
+				BinaryHeader: BinaryHeader{Type: 6, Repeat: 0, MMSI: 2655087, DestMMSI: 266119000, DAC: 1, FID: 30},
+				LinkageID:    77, Text: "BERTH 12 ASSIGNED"},
+		},
+		{ // Text longer than the fields of the common message types
+			"800000@0G@11111111111111111111111111111111111111111111111111111111111111111111111",
+			TextDescription{
+				BinaryHeader: BinaryHeader{Type: 8, Repeat: 0, MMSI: 1, DAC: 1, FID: 29},
+				LinkageID:    1, Text: strings.Repeat("A", 70)},
+		},
+	}
+	for _, c := range cases {
+		got, _ := DecodeTextDescription(c.payload)
+		if got != c.want {
+			fmt.Println("Got : ", got)
+			fmt.Println("Want: ", c.want)
+			t.Errorf("DecodeTextDescription(payload string)")
+		}
+	}
+}
+
+func TestDecodeTextTelegram(t *testing.T) {
+	want := TextTelegram{
+		BinaryHeader: BinaryHeader{Type: 8, Repeat: 0, MMSI: 2655087, DAC: 1, FID: 0},
+		AckRequired:  true, Sequence: 1025, Text: "GALE WARNING"}
+	got, _ := DecodeTextTelegram("802R3Kh0@<0AhC1H5hDSRCQh")
+	if got != want {
+		fmt.Println("Got : ", got)
+		fmt.Println("Want: ", want)
+		t.Errorf("DecodeTextTelegram(payload string)")
+	}
+}
+
+func TestTextLinker(t *testing.T) {
+	linker := NewTextLinker()
+	text, _ := DecodeTextDescription("85Mwp`00GH181B2?EBP3<?C54P4E5PD?P4B5479>7dPEC5P>?BD8P381>>5<")
+	linker.Add(text)
+
+	// An area notice (FID 22) from the same station, with the same linkage ID
+	got, ok := linker.Describe("85Mwp`00E`12VqKh0?0")
+	if !ok || got != text {
+		fmt.Println("Got : ", got)
+		fmt.Println("Want: ", text)
+		t.Errorf("TextLinker.Describe(payload string)")
+	}
+
+	// Same linkage ID but from another station
+	if _, ok = linker.Lookup(2655087, 513); ok {
+		t.Errorf("TextLinker.Lookup(mmsi uint32, linkageID uint16)")
+	}
+}
@@ -0,0 +1,430 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sentences exchanged with an AIS transponder, besides AIVDM/AIVDO. The unit reports alarms
+// (ALR), status text (TXT) and acknowledgements (ABK), and it is configured with voyage (VSD)
+// and station (SSD) data and asked to transmit binary messages (ABM, BBM).
+//
+// Many fields of these sentences may be null, which for configuration sentences means "leave
+// unchanged". Null numbers are -1 (NaN for draught), null text is empty and a null time is zero.
+
+// ALR is an alarm reported by the transponder.
+type ALR struct {
+	NMEAHeader
+	Time         time.Time // Time of alarm condition change (UTC), year is left at 0
+	Alarm        int       // Alarm number, see TransponderAlarms
+	Active       bool      // Alarm condition: threshold exceeded
+	Acknowledged bool
+	Description  string
+}
+
+// TXT is a text message (e.g status of the transponder). Long texts span many sentences.
+type TXT struct {
+	NMEAHeader
+	Total  int // Number of sentences of the text
+	Number int // Number of this sentence
+	ID     int // Text identifier, see TransponderAlarms
+	Text   string
+}
+
+// VSD is the voyage static data of the own vessel.
+type VSD struct {
+	NMEAHeader
+	ShipType       int     // Type of ship and cargo category, see ShipType
+	Draught        float64 // Maximum present static draught (meters)
+	PersonsOnBoard int
+	Destination    string
+	ETA            time.Time // Estimated time of arrival (UTC), year isn't transmitted
+	NavStatus      int       // Navigational status, see NavigationStatusCodes
+	RegionalFlags  int       // Regional application flags
+}
+
+// SSD is the static data of the own station.
+type SSD struct {
+	NMEAHeader
+	Callsign    string
+	VesselName  string
+	ToBow       int // Position of the reference point of the antenna, dimension to bow
+	ToStern     int // Dimension to stern
+	ToPort      int // Dimension to port
+	ToStarboard int // Dimension to starboard
+	DTE         bool
+	Source      string // Talker ID of the antenna the data refer to, e.g "AI" for the internal one
+}
+
+// ABK is the acknowledgement of a message the transponder was asked to transmit (ABM, BBM)
+// or received.
+type ABK struct {
+	NMEAHeader
+	MMSI      uint32 // MMSI of the addressed station
+	Channel   string // AIS channel of reception ("A" or "B")
+	MessageID int    // ITU-R M.1371 message type
+	Sequence  int    // Sequential message identifier of the ABM/BBM
+	AckType   int    // Type of acknowledgement, see AcknowledgementTypes
+}
+
+// ABM asks the transponder to transmit an addressed binary or safety related message.
+// Parsed sentences hold one part of the message; to generate sentences, set Data to the whole
+// payload and Sentences splits it.
+type ABM struct {
+	NMEAHeader
+	Total     int    // Number of sentences of the message
+	Number    int    // Number of this sentence
+	Sequence  int    // Sequential message identifier (0-3)
+	DestMMSI  uint32 // MMSI of the addressed station
+	Channel   int    // Channel for broadcast: 0 no preference, 1 A, 2 B, 3 both
+	MessageID int    // ITU-R M.1371 message type: 6, 12, 25 or 26
+	Data      string // Armoured (six bit ASCII) message data
+	Padding   uint8  // Fill bits
+}
+
+// BBM asks the transponder to transmit a broadcast binary or safety related message. Like ABM,
+// parsed sentences hold one part of the message.
+type BBM struct {
+	NMEAHeader
+	Total     int
+	Number    int
+	Sequence  int    // Sequential message identifier (0-9)
+	Channel   int    // Channel for broadcast: 0 no preference, 1 A, 2 B, 3 both
+	MessageID int    // ITU-R M.1371 message type: 8, 14, 25 or 26
+	Data      string // Armoured (six bit ASCII) message data
+	Padding   uint8  // Fill bits
+}
+
+// Formatter returns "ALR".
+func (ALR) Formatter() string { return "ALR" }
+
+// Formatter returns "TXT".
+func (TXT) Formatter() string { return "TXT" }
+
+// Formatter returns "VSD".
+func (VSD) Formatter() string { return "VSD" }
+
+// Formatter returns "SSD".
+func (SSD) Formatter() string { return "SSD" }
+
+// Formatter returns "ABK".
+func (ABK) Formatter() string { return "ABK" }
+
+// Formatter returns "ABM".
+func (ABM) Formatter() string { return "ABM" }
+
+// Formatter returns "BBM".
+func (BBM) Formatter() string { return "BBM" }
+
+// Alarms and text identifiers of AIS transponders (IEC 61993-2).
+var TransponderAlarms = map[int]string{
+	1:  "Tx malfunction",
+	2:  "Antenna VSWR exceeds limit",
+	3:  "Rx channel 1 malfunction",
+	4:  "Rx channel 2 malfunction",
+	5:  "Rx channel 70 malfunction",
+	6:  "General failure",
+	8:  "MKD connection lost",
+	21: "External DGNSS in use",
+	22: "External GNSS in use",
+	23: "Internal DGNSS in use (beacon)",
+	24: "Internal DGNSS in use (message 17)",
+	25: "External EPFS lost",
+	26: "No sensor position in use",
+	27: "Internal GNSS in use",
+	29: "No valid SOG information",
+	30: "No valid COG information",
+	31: "External SOG/COG in use",
+	32: "Heading lost/invalid",
+	33: "Internal SOG/COG in use",
+	34: "Heading valid",
+	35: "No valid ROT information",
+	36: "Rate of Turn Indicator in use",
+	37: "Other ROT source in use",
+	38: "Channel management parameters changed",
+}
+
+// Types of acknowledgement of ABK sentences.
+var AcknowledgementTypes = [...]string{
+	"Message (6 or 12) successfully received by the addressed station",
+	"Message (6 or 12) was broadcast, but no acknowledgement by the addressed station",
+	"Message could not be broadcast",
+	"Requested broadcast of message (8, 14 or 15) has been successfully completed",
+	"Late reception of a message 7 or 13 acknowledgement",
+}
+
+// nmeaInt parses an integer, missing or invalid integers are -1.
+func nmeaInt(field string) int {
+	i, err := strconv.Atoi(field)
+	if err != nil {
+		return -1
+	}
+	return i
+}
+
+func parseALR(fields []string) (NMEASentence, error) {
+	var s ALR
+	if err := nmeaFields(fields, 6); err != nil {
+		return s, err
+	}
+	s.Talker = fields[0][1:3]
+
+	if fields[1] != "" {
+		var err error
+		if s.Time, err = nmeaTime(fields[1], time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)); err != nil {
+			return s, err
+		}
+	}
+	s.Alarm = nmeaInt(fields[2])
+	s.Active = fields[3] == "A"
+	s.Acknowledged = fields[4] == "A"
+	s.Description = fields[5]
+	return s, nil
+}
+
+func parseTXT(fields []string) (NMEASentence, error) {
+	var s TXT
+	if err := nmeaFields(fields, 5); err != nil {
+		return s, err
+	}
+	s.Talker = fields[0][1:3]
+
+	s.Total = nmeaInt(fields[1])
+	s.Number = nmeaInt(fields[2])
+	s.ID = nmeaInt(fields[3])
+	s.Text = fields[4]
+	return s, nil
+}
+
+func parseVSD(fields []string) (NMEASentence, error) {
+	var s VSD
+	if err := nmeaFields(fields, 10); err != nil {
+		return s, err
+	}
+	s.Talker = fields[0][1:3]
+
+	s.ShipType = nmeaInt(fields[1])
+	s.Draught = nmeaFloat(fields[2])
+	s.PersonsOnBoard = nmeaInt(fields[3])
+	s.Destination = fields[4]
+	if fields[5] != "" || fields[6] != "" || fields[7] != "" {
+		date, err := time.Parse("1 2", fields[7]+" "+fields[6])
+		if err != nil {
+			return s, errors.New("ETA date '" + fields[6] + "," + fields[7] + "' isn't valid.")
+		}
+		if s.ETA, err = nmeaTime(fields[5], date); err != nil {
+			return s, err
+		}
+	}
+	s.NavStatus = nmeaInt(fields[8])
+	s.RegionalFlags = nmeaInt(fields[9])
+	return s, nil
+}
+
+func parseSSD(fields []string) (NMEASentence, error) {
+	var s SSD
+	if err := nmeaFields(fields, 9); err != nil {
+		return s, err
+	}
+	s.Talker = fields[0][1:3]
+
+	s.Callsign = fields[1]
+	s.VesselName = fields[2]
+	s.ToBow = nmeaInt(fields[3])
+	s.ToStern = nmeaInt(fields[4])
+	s.ToPort = nmeaInt(fields[5])
+	s.ToStarboard = nmeaInt(fields[6])
+	s.DTE = fields[7] == "1"
+	s.Source = fields[8]
+	return s, nil
+}
+
+func parseABK(fields []string) (NMEASentence, error) {
+	var s ABK
+	if err := nmeaFields(fields, 6); err != nil {
+		return s, err
+	}
+	s.Talker = fields[0][1:3]
+
+	mmsi, _ := strconv.ParseUint(fields[1], 10, 32)
+	s.MMSI = uint32(mmsi)
+	s.Channel = fields[2]
+	s.MessageID = nmeaInt(fields[3])
+	s.Sequence = nmeaInt(fields[4])
+	s.AckType = nmeaInt(fields[5])
+	return s, nil
+}
+
+func parseABM(fields []string) (NMEASentence, error) {
+	var s ABM
+	if err := nmeaFields(fields, 9); err != nil {
+		return s, err
+	}
+	s.Talker = fields[0][1:3]
+
+	s.Total = nmeaInt(fields[1])
+	s.Number = nmeaInt(fields[2])
+	s.Sequence = nmeaInt(fields[3])
+	mmsi, _ := strconv.ParseUint(fields[4], 10, 32)
+	s.DestMMSI = uint32(mmsi)
+	s.Channel = nmeaInt(fields[5])
+	s.MessageID = nmeaInt(fields[6])
+	s.Data = fields[7]
+	padding, _ := strconv.Atoi(fields[8])
+	s.Padding = uint8(padding)
+	return s, nil
+}
+
+func parseBBM(fields []string) (NMEASentence, error) {
+	var s BBM
+	if err := nmeaFields(fields, 8); err != nil {
+		return s, err
+	}
+	s.Talker = fields[0][1:3]
+
+	s.Total = nmeaInt(fields[1])
+	s.Number = nmeaInt(fields[2])
+	s.Sequence = nmeaInt(fields[3])
+	s.Channel = nmeaInt(fields[4])
+	s.MessageID = nmeaInt(fields[5])
+	s.Data = fields[6]
+	padding, _ := strconv.Atoi(fields[7])
+	s.Padding = uint8(padding)
+	return s, nil
+}
+
+// nmeaSentence builds a sentence with its checksum. Start is '$' for parametric sentences and
+// '!' for encapsulation sentences.
+func nmeaSentence(start byte, talker, formatter string, fields ...string) (string, error) {
+	if len(talker) != 2 {
+		return "", errors.New("Talker ID '" + talker + "' isn't valid.")
+	}
+	data := talker + formatter + "," + strings.Join(fields, ",")
+	sentence := fmt.Sprintf("%c%s*%02X", start, data, Nmea183Checksum(data))
+	if len(sentence) > MaxSentenceLength {
+		return "", errors.New("Sentence " + formatter + " is longer than " + strconv.Itoa(MaxSentenceLength) + " characters.")
+	}
+	return sentence, nil
+}
+
+// nmeaFormatInt formats an integer, negative integers are null.
+func nmeaFormatInt(i int) string {
+	if i < 0 {
+		return ""
+	}
+	return strconv.Itoa(i)
+}
+
+// Sentence returns the VSD sentence that sets the voyage static data of a transponder.
+func (s VSD) Sentence() (string, error) {
+	draught := ""
+	if !math.IsNaN(s.Draught) && s.Draught >= 0 {
+		draught = strconv.FormatFloat(s.Draught, 'f', 1, 64)
+	}
+	eta := []string{"", "", ""}
+	if !s.ETA.IsZero() {
+		eta = []string{s.ETA.Format("150405.00"), s.ETA.Format("02"), s.ETA.Format("01")}
+	}
+	return nmeaSentence('$', s.Talker, "VSD", nmeaFormatInt(s.ShipType), draught,
+		nmeaFormatInt(s.PersonsOnBoard), s.Destination, eta[0], eta[1], eta[2],
+		nmeaFormatInt(s.NavStatus), nmeaFormatInt(s.RegionalFlags))
+}
+
+// Sentence returns the SSD sentence that sets the static data of a transponder.
+func (s SSD) Sentence() (string, error) {
+	dte := "0"
+	if s.DTE {
+		dte = "1"
+	}
+	return nmeaSentence('$', s.Talker, "SSD", s.Callsign, s.VesselName, nmeaFormatInt(s.ToBow),
+		nmeaFormatInt(s.ToStern), nmeaFormatInt(s.ToPort), nmeaFormatInt(s.ToStarboard), dte, s.Source)
+}
+
+// encapsulatedSentences splits the data of an ABM/BBM message into sentences. Fields holds
+// the fields between the sequential message identifier and the data.
+func encapsulatedSentences(talker, formatter string, sequence int, fields []string, data string, padding uint8) ([]string, error) {
+	if len(talker) != 2 {
+		return nil, errors.New("Talker ID '" + talker + "' isn't valid.")
+	}
+	if padding > 5 {
+		return nil, errors.New("Padding should be between 0 and 5.")
+	}
+	if len(data) == 0 {
+		return nil, errors.New("Message has no data.")
+	}
+
+	overhead := len(fmt.Sprintf("!%s%s,1,1,%d,%s,,0*00", talker, formatter, sequence, strings.Join(fields, ",")))
+	size := MaxSentenceLength - overhead
+	if size <= 0 {
+		return nil, errors.New("Sentence " + formatter + " has no room for data.")
+	}
+	count := (len(data) + size - 1) / size
+	if count > 9 {
+		return nil, errors.New("Data are too long, they need more than 9 sentences.")
+	}
+
+	sentences := make([]string, count)
+	for i := range sentences {
+		part := data[i*size:]
+		p := uint8(0)
+		if len(part) > size {
+			part = part[:size]
+		} else {
+			p = padding // Only the last sentence has fill bits
+		}
+		f := append([]string{strconv.Itoa(count), strconv.Itoa(i + 1), strconv.Itoa(sequence)}, fields...)
+		f = append(f, part, strconv.Itoa(int(p)))
+		var err error
+		if sentences[i], err = nmeaSentence('!', talker, formatter, f...); err != nil {
+			return nil, err
+		}
+	}
+	return sentences, nil
+}
+
+// Sentences returns the ABM sentences that ask a transponder to transmit Data. Total and
+// Number are ignored.
+func (s ABM) Sentences() ([]string, error) {
+	if s.MessageID != 6 && s.MessageID != 12 && s.MessageID != 25 && s.MessageID != 26 {
+		return nil, errors.New("ABM message ID should be 6, 12, 25 or 26.")
+	}
+	if s.Sequence < 0 || s.Sequence > 3 || s.Channel < 0 || s.Channel > 3 {
+		return nil, errors.New("ABM sequence and channel should be between 0 and 3.")
+	}
+	return encapsulatedSentences(s.Talker, "ABM", s.Sequence, []string{fmt.Sprintf("%09d", s.DestMMSI),
+		strconv.Itoa(s.Channel), strconv.Itoa(s.MessageID)}, s.Data, s.Padding)
+}
+
+// Sentences returns the BBM sentences that ask a transponder to transmit Data. Total and
+// Number are ignored.
+func (s BBM) Sentences() ([]string, error) {
+	if s.MessageID != 8 && s.MessageID != 14 && s.MessageID != 25 && s.MessageID != 26 {
+		return nil, errors.New("BBM message ID should be 8, 14, 25 or 26.")
+	}
+	if s.Sequence < 0 || s.Sequence > 9 || s.Channel < 0 || s.Channel > 3 {
+		return nil, errors.New("BBM sequence should be between 0 and 9 and channel between 0 and 3.")
+	}
+	return encapsulatedSentences(s.Talker, "BBM", s.Sequence, []string{strconv.Itoa(s.Channel),
+		strconv.Itoa(s.MessageID)}, s.Data, s.Padding)
+}
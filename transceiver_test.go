@@ -0,0 +1,149 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTransceiverSentences(t *testing.T) {
+	cases := []struct {
+		sentence string
+		want     NMEASentence
+	}{
+		{
+			"$AIALR,123519.00,002,A,V,AIS: Antenna VSWR exceeds limit*5F",
+			ALR{NMEAHeader: NMEAHeader{"AI"}, Time: time.Date(0, 1, 1, 12, 35, 19, 0, time.UTC), Alarm: 2,
+				Active: true, Acknowledged: false, Description: "AIS: Antenna VSWR exceeds limit"},
+		},
+		{
+			"$AITXT,01,01,22,External GNSS in use*20",
+			TXT{NMEAHeader: NMEAHeader{"AI"}, Total: 1, Number: 1, ID: 22, Text: "External GNSS in use"},
+		},
+		{
+			"$AIABK,366999712,A,06,1,0*14",
+			ABK{NMEAHeader: NMEAHeader{"AI"}, MMSI: 366999712, Channel: "A", MessageID: 6, Sequence: 1, AckType: 0},
+		},
+		{
+			"$AIVSD,70,7.5,25,ROTTERDAM,163000.00,24,12,0,0*24",
+			VSD{NMEAHeader: NMEAHeader{"AI"}, ShipType: 70, Draught: 7.5, PersonsOnBoard: 25,
+				Destination: "ROTTERDAM", ETA: time.Date(0, 12, 24, 16, 30, 0, 0, time.UTC), NavStatus: 0,
+				RegionalFlags: 0},
+		},
+		{
+			"$AIVSD,,,,,,,,5,*50",
+			VSD{NMEAHeader: NMEAHeader{"AI"}, ShipType: -1, Draught: math.NaN(), PersonsOnBoard: -1,
+				NavStatus: 5, RegionalFlags: -1},
+		},
+		{
+			"$AISSD,PD5678,ROTTERDAM EXPRESS,120,30,10,12,0,AI*66",
+			SSD{NMEAHeader: NMEAHeader{"AI"}, Callsign: "PD5678", VesselName: "ROTTERDAM EXPRESS", ToBow: 120,
+				ToStern: 30, ToPort: 10, ToStarboard: 12, DTE: false, Source: "AI"},
+		},
+		{
+			"!ECABM,1,1,0,366999712,1,6,04a1Di0,2*3A",
+			ABM{NMEAHeader: NMEAHeader{"EC"}, Total: 1, Number: 1, Sequence: 0, DestMMSI: 366999712, Channel: 1,
+				MessageID: 6, Data: "04a1Di0", Padding: 2},
+		},
+		{
+			"!ECBBM,1,1,3,0,8,04a1Di0,2*27",
+			BBM{NMEAHeader: NMEAHeader{"EC"}, Total: 1, Number: 1, Sequence: 3, Channel: 0, MessageID: 8,
+				Data: "04a1Di0", Padding: 2},
+		},
+	}
+	for _, c := range cases {
+		got, err := ParseNMEASentence(c.sentence)
+		if err != nil || !nmeaEqual(got, c.want) {
+			fmt.Printf("Got : %+v %v\n", got, err)
+			fmt.Printf("Want: %+v\n", c.want)
+			t.Errorf("ParseNMEASentence(sentence string)")
+		}
+	}
+
+	if _, err := ParseNMEASentence("$AIVSD,70,7.5,25,ROTTERDAM,163000.00,32,12,0,0"); err == nil {
+		t.Errorf("ParseNMEASentence(sentence string) accepted an invalid ETA")
+	}
+}
+
+func TestGenerateTransceiverSentences(t *testing.T) {
+	vsd := VSD{NMEAHeader: NMEAHeader{"AI"}, ShipType: 70, Draught: 7.5, PersonsOnBoard: 25,
+		Destination: "ROTTERDAM", ETA: time.Date(0, 12, 24, 16, 30, 0, 0, time.UTC), NavStatus: 0, RegionalFlags: 0}
+	if got, err := vsd.Sentence(); err != nil || got != "$AIVSD,70,7.5,25,ROTTERDAM,163000.00,24,12,0,0*24" {
+		t.Errorf("VSD.Sentence() = %s, %v", got, err)
+	}
+
+	// Only change the navigational status
+	vsd = VSD{NMEAHeader: NMEAHeader{"AI"}, ShipType: -1, Draught: math.NaN(), PersonsOnBoard: -1,
+		NavStatus: 5, RegionalFlags: -1}
+	if got, err := vsd.Sentence(); err != nil || got != "$AIVSD,,,,,,,,5,*50" {
+		t.Errorf("VSD.Sentence() = %s, %v", got, err)
+	}
+
+	ssd := SSD{NMEAHeader: NMEAHeader{"AI"}, Callsign: "PD5678", VesselName: "ROTTERDAM EXPRESS", ToBow: 120,
+		ToStern: 30, ToPort: 10, ToStarboard: 12, DTE: false, Source: "AI"}
+	if got, err := ssd.Sentence(); err != nil || got != "$AISSD,PD5678,ROTTERDAM EXPRESS,120,30,10,12,0,AI*66" {
+		t.Errorf("SSD.Sentence() = %s, %v", got, err)
+	}
+
+	abm := ABM{NMEAHeader: NMEAHeader{"EC"}, DestMMSI: 366999712, Channel: 1, MessageID: 6, Data: "04a1Di0", Padding: 2}
+	if got, err := abm.Sentences(); err != nil || !reflect.DeepEqual(got, []string{"!ECABM,1,1,0,366999712,1,6,04a1Di0,2*3A"}) {
+		t.Errorf("ABM.Sentences() = %v, %v", got, err)
+	}
+
+	// Long data span many sentences, parsing them back gives the data
+	bbm := BBM{NMEAHeader: NMEAHeader{"EC"}, Sequence: 3, MessageID: 8, Data: strings.Repeat("04a1Di0", 20), Padding: 2}
+	got, err := bbm.Sentences()
+	data := ""
+	for i, s := range got {
+		p, _ := ParseNMEASentence(s)
+		part := p.(BBM)
+		if !Nmea183ChecksumCheck(s) || len(s) > MaxSentenceLength || part.Total != len(got) || part.Number != i+1 {
+			t.Errorf("BBM.Sentences() returned invalid sentence %s", s)
+		}
+		data += part.Data
+	}
+	if err != nil || len(got) != 3 || data != bbm.Data {
+		t.Errorf("BBM.Sentences() = %v, %v", got, err)
+	}
+
+	invalid := []interface {
+		Sentences() ([]string, error)
+	}{
+		ABM{NMEAHeader: NMEAHeader{"EC"}, MessageID: 8, Data: "0"},
+		ABM{NMEAHeader: NMEAHeader{"EC"}, MessageID: 6, Sequence: 4, Data: "0"},
+		ABM{NMEAHeader: NMEAHeader{"E"}, MessageID: 6, Data: "0"},
+		BBM{NMEAHeader: NMEAHeader{"EC"}, MessageID: 6, Data: "0"},
+		BBM{NMEAHeader: NMEAHeader{"EC"}, MessageID: 8, Data: strings.Repeat("0", 600)},
+		BBM{NMEAHeader: NMEAHeader{"EC"}, MessageID: 8},
+		BBM{NMEAHeader: NMEAHeader{strings.Repeat("E", 60)}, MessageID: 8, Data: "0"}, // No room for data
+		BBM{NMEAHeader: NMEAHeader{strings.Repeat("E", 70)}, MessageID: 8, Data: "0"},
+	}
+	for _, s := range invalid {
+		if got, err := s.Sentences(); err == nil {
+			t.Errorf("%T.Sentences() = %v, want error", s, got)
+		}
+	}
+	if got, err := (SSD{NMEAHeader: NMEAHeader{"AI"}, VesselName: strings.Repeat("A", 80)}).Sentence(); err == nil {
+		t.Errorf("SSD.Sentence() = %v, want error", got)
+	}
+}
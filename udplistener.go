@@ -0,0 +1,181 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A UDPListener receives NMEA sentences from AIS receivers that broadcast them over UDP
+// (dAISy, AIS-catcher, rtl-ais and most others). A datagram may carry several sentences,
+// separated by LF or CRLF.
+//
+// Sentences are processed with the logic of Router, separately for every sender, so the
+// multi-sentence messages of different receivers don't mix. Messages carry the address of
+// their sender as Source, unless the sentences come with a source of their own (tag blocks
+// when Options.Archive is set).
+type UDPListener struct {
+	// Options are the RouterOptions used for the sentences. Set them before calling Run.
+	Options RouterOptions
+	// Failed, if set, is called for every sentence that can't be used. It is called from the
+	// goroutine of Run.
+	Failed func(FailedSentence)
+	// IdleTimeout is how long the state of a silent sender is kept. Then the incomplete
+	// messages of the sender are dropped as failed. Zero means DefaultUDPIdleTimeout.
+	IdleTimeout time.Duration
+
+	conn     net.PacketConn
+	counters sourceCounters
+	running  int32
+	senders  int32
+	closing  sync.Once
+}
+
+// DefaultUDPIdleTimeout is the IdleTimeout of a UDPListener when it isn't set.
+const DefaultUDPIdleTimeout = 5 * time.Minute
+
+// udpSender is the state of a sender of a UDPListener.
+type udpSender struct {
+	router *router
+	last   time.Time
+}
+
+// ListenUDP listens for datagrams on address (host:port, the host may be empty). Use port 0
+// to let the system pick one, and Addr to find it.
+func ListenUDP(address string) (*UDPListener, error) {
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	return &UDPListener{conn: conn}, nil
+}
+
+// Addr returns the address the listener listens on.
+func (l *UDPListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
+
+// Stats returns the counters of the listener. It is safe to call while Run is running.
+func (l *UDPListener) Stats() SourceStats {
+	return l.counters.get()
+}
+
+// Senders returns the number of senders the listener keeps state for. It is safe to call while
+// Run is running.
+func (l *UDPListener) Senders() int {
+	return int(atomic.LoadInt32(&l.senders))
+}
+
+// Close stops the listener. A running Run returns.
+func (l *UDPListener) Close() error {
+	var err error
+	l.closing.Do(func() { err = l.conn.Close() })
+	return err
+}
+
+// Run receives datagrams and sends the messages they carry to out, until ctx is cancelled or
+// the listener is closed. Then it closes the listener and returns the context's error, or the
+// error that stopped it. A listener can run only once.
+func (l *UDPListener) Run(ctx context.Context, out chan<- Message) error {
+	if !atomic.CompareAndSwapInt32(&l.running, 0, 1) {
+		return errors.New("UDPListener has already run.")
+	}
+	defer l.Close()
+
+	// Unblock reads when the context is cancelled
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			l.Close()
+		case <-stop:
+		}
+	}()
+
+	send := func(m Message) {
+		select {
+		case out <- m:
+		case <-ctx.Done():
+		}
+	}
+	failed := func(f FailedSentence) {
+		if l.Failed != nil {
+			l.Failed(f)
+		}
+	}
+
+	idle := l.IdleTimeout
+	if idle <= 0 {
+		idle = DefaultUDPIdleTimeout
+	}
+	senders := make(map[string]*udpSender)
+	swept := time.Now()
+	buf := make([]byte, 65536)
+	for {
+		n, addr, err := l.conn.ReadFrom(buf)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		l.counters.update(func(s *SourceStats) {
+			s.Bytes += int64(n)
+			s.LastData = now
+		})
+
+		// Forget the senders that went silent, so that the map doesn't grow forever
+		if now.Sub(swept) >= idle/2 {
+			for k, s := range senders {
+				if now.Sub(s.last) >= idle {
+					for _, c := range s.router.cache[:s.router.count] {
+						failed(FailedSentence{c, "Incomplete/out of order span sentence"})
+					}
+					delete(senders, k)
+				}
+			}
+			swept = now
+		}
+
+		sender := addr.String()
+		s, ok := senders[sender]
+		if !ok {
+			s = &udpSender{router: newRouter(l.Options, send, failed)}
+			s.router.defaults.Source = sender
+			senders[sender] = s
+		}
+		s.last = now
+		atomic.StoreInt32(&l.senders, int32(len(senders)))
+		r := s.router
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			if line = strings.TrimSuffix(line, "\r"); line == "" {
+				continue
+			}
+			l.counters.update(func(s *SourceStats) { s.Lines++ })
+			r.process(line)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestUDPListener(t *testing.T) {
+	l, err := ListenUDP("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var failed []FailedSentence
+	l.Failed = func(f FailedSentence) { failed = append(failed, f) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan Message, 16)
+	done := make(chan error)
+	go func() { done <- l.Run(ctx, out) }()
+
+	a, err := net.Dial("udp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := net.Dial("udp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	// The parts of the multi-sentence messages of the two senders interleave
+	part1 := "!AIVDM,2,1,5,A,533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H,0*44\r\n"
+	part2 := "!AIVDM,2,2,5,A,51CU0E2CkP0,2*0C\r\n"
+	datagrams := []struct {
+		conn net.Conn
+		data string
+	}{
+		{a, part1},
+		{b, part1},
+		{a, part2},
+		{b, part2 + "!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F\n\n!AIVDM,1,1,,B,bad*00\n"},
+	}
+	for _, d := range datagrams {
+		if _, err := d.conn.Write([]byte(d.data)); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond) // Keep the order
+	}
+
+	long := "533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H51CU0E2CkP0"
+	want := []Message{
+		{Type: 5, Payload: long, Padding: 2, Source: a.LocalAddr().String()},
+		{Type: 5, Payload: long, Padding: 2, Source: b.LocalAddr().String()},
+		{Type: 3, Payload: "38u<a<?PAA2>P:WfuAO9PW<P0PuQ", Source: b.LocalAddr().String()},
+	}
+	for _, w := range want {
+		select {
+		case got := <-out:
+			if got != w {
+				t.Errorf("UDPListener.Run() sent %v, want %v", got, w)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("UDPListener.Run() didn't send %v", w)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("UDPListener.Run() = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("UDPListener.Run() didn't stop when cancelled")
+	}
+
+	if len(failed) != 1 || failed[0].Issue != "Checksum failed" {
+		t.Errorf("UDPListener failed sentences %v, want one with bad checksum", failed)
+	}
+	if stats := l.Stats(); stats.Lines != 6 || stats.Bytes != int64(2*len(part1)+len(datagrams[3].data)+len(part2)) {
+		t.Errorf("UDPListener.Stats() = %+v", stats)
+	}
+	if err := l.Run(context.Background(), out); err == nil {
+		t.Errorf("UDPListener.Run() ran twice")
+	}
+}
+
+func TestUDPListenerIdle(t *testing.T) {
+	l, err := ListenUDP("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.IdleTimeout = 100 * time.Millisecond
+	failed := make(chan FailedSentence, 16)
+	l.Failed = func(f FailedSentence) { failed <- f }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	out := make(chan Message, 16)
+	go l.Run(ctx, out)
+
+	a, err := net.Dial("udp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := net.Dial("udp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	part1 := "!AIVDM,2,1,5,A,533iFNT00003W;3G;384iT<T400000000000001?88?73v0ik0RC1H11H30H,0*44"
+	part2 := "!AIVDM,2,2,5,A,51CU0E2CkP0,2*0C"
+	single := "!AIVDM,1,1,,B,38u<a<?PAA2>P:WfuAO9PW<P0PuQ,0*6F"
+	a.Write([]byte(part1))
+	b.Write([]byte(single))
+	waitFor(t, "two senders", func() bool { return l.Senders() == 2 })
+	<-out
+
+	// Both go silent, then b comes back: the state of a is dropped with its first part
+	time.Sleep(150 * time.Millisecond)
+	b.Write([]byte(single))
+	<-out
+	if l.Senders() != 1 {
+		t.Errorf("UDPListener.Senders() = %d after a sender went silent, want 1", l.Senders())
+	}
+	select {
+	case f := <-failed:
+		if f.Sentence != part1 {
+			t.Errorf("UDPListener failed %q, want %q", f.Sentence, part1)
+		}
+	default:
+		t.Errorf("UDPListener dropped a sender without failing its incomplete message")
+	}
+
+	// The second part comes too late
+	a.Write([]byte(part2))
+	select {
+	case f := <-failed:
+		if f.Sentence != part2 {
+			t.Errorf("UDPListener failed %q, want %q", f.Sentence, part2)
+		}
+	case m := <-out:
+		t.Errorf("UDPListener.Run() sent %v from parts of different times", m)
+	case <-time.After(time.Second):
+		t.Fatalf("UDPListener didn't fail a second part without its first")
+	}
+	if l.Senders() != 2 {
+		t.Errorf("UDPListener.Senders() = %d, want 2", l.Senders())
+	}
+}
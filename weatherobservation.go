@@ -0,0 +1,213 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"errors"
+	"math"
+)
+
+// WeatherObservation is a DAC 1, FID 21 message. Ships send it either in a simple (non-WMO)
+// or in the WMO format. Fields common to both formats are converted to the same units, so
+// observations can be handled together with other meteorological data. Floating point fields
+// that aren't available are set to NaN.
+type WeatherObservation struct {
+	BinaryHeader
+	WMO              bool   // true for the WMO variant
+	Location         string // Geographic location, non-WMO only
+	Lon              float64
+	Lat              float64
+	Month            uint8   // UTC, WMO only, 0: not available
+	Day              uint8   // UTC, 0: not available
+	Hour             uint8   // UTC, 24: not available
+	Minute           uint8   // UTC, 60 (non-WMO): not available. The WMO variant has 10 minutes resolution
+	PresentWeather   uint16  // non-WMO: enumerated, see WeatherObservationTypes, WMO: WMO code table 4677
+	Visibility       float32 // Nautical miles, non-WMO only
+	Humidity         uint8   // Relative humidity %, 101 (non-WMO) or 127 (WMO): not available
+	WindSpeed        float32 // Average (true) wind speed in knots
+	WindDirection    uint16  // degrees, 360 (non-WMO) or 635 (WMO): not available
+	Pressure         float32 // hPa
+	PressureTendency uint8   // non-WMO: 0 steady, 1 decreasing, 2 increasing, WMO: WMO code table 0200
+	AirTemperature   float32 // Celsius
+	SeaTemperature   float32 // Celsius
+	WavePeriod       uint8   // seconds
+	WaveHeight       float32 // meters
+	WaveDirection    uint16  // degrees, non-WMO only
+	SwellHeight      float32 // meters
+	SwellDirection   uint16  // degrees
+	SwellPeriod      uint8   // seconds
+	WMOData          WMOWeatherData
+}
+
+// WMOWeatherData are the fields found only in the WMO variant of the Weather Observation
+// message. Values are as transmitted, encoded according to the WMO code tables.
+type WMOWeatherData struct {
+	Course              uint8 // Course of ship ×5 degrees
+	Speed               uint8 // Speed of ship ×0.5 m/s
+	Heading             uint8 // Heading of ship ×5 degrees
+	PressureChange      int16 // hPa/10, change in the last 3 hours
+	RelativeWindSpeed   uint8 // ×0.5 m/s
+	RelativeWindDir     uint8 // ×5 degrees
+	GustSpeed           uint8 // Maximum gust speed ×0.5 m/s
+	GustDirection       uint8 // Maximum gust direction ×5 degrees
+	VisibilityCode      uint8 // WMO code table 4377
+	PastWeather1        uint8 // WMO code table 4561
+	PastWeather2        uint8 // WMO code table 4561
+	CloudCover          uint8 // Total cloud cover, WMO code table 2700
+	LowCloudAmount      uint8 // WMO code table 2700
+	LowCloudType        uint8 // WMO code table 0513
+	MiddleCloudType     uint8 // WMO code table 0515
+	HighCloudType       uint8 // WMO code table 0509
+	CloudBase           uint8 // Height of base of lowest cloud, WMO code table 1600
+	SecondSwellDir      uint8 // ×10 degrees
+	SecondSwellPeriod   uint8 // seconds
+	SecondSwellHeight   uint8 // ×0.5 meters
+	IceThickness        uint8 // Ice deposit thickness in centimeters
+	IceAccretionRate    uint8 // WMO code table 3551
+	IceAccretionCause   uint8 // WMO code table 3551
+	SeaIceConcentration uint8 // WMO code table 0639
+}
+
+var WeatherObservationTypes = [...]string{
+	"Clear (no clouds)", "Cloudy", "Rain", "Fog", "Snow", "Typhoon/hurricane", "Monsoon",
+	"Thunderstorm", "Not available", "reserved", "reserved", "reserved", "reserved",
+	"reserved", "reserved", "reserved",
+}
+
+// scaledValue converts a raw field to a floating point value, returning NaN if the field
+// has the not available value.
+func scaledValue(raw int32, na int32, scale, offset float64) float32 {
+	if raw == na {
+		return float32(math.NaN())
+	}
+	return float32(float64(raw)*scale + offset)
+}
+
+// knotsPerMeterSecond converts the m/s based speeds of the WMO variant to knots.
+const knotsPerMeterSecond = 3600.0 / 1852.0
+
+// DecodeWeatherObservation decodes the payload of a Weather Observation Report from Ship
+// message (type 8 DAC 1 FID 21).
+func DecodeWeatherObservation(payload string) (WeatherObservation, error) {
+	r := NewBitReader(payload)
+	var m WeatherObservation
+
+	m.BinaryHeader, _ = decodeBinaryHeader(r)
+	if m.Type != 8 || m.DAC != 1 || m.FID != 21 {
+		return m, errors.New("Message isn't Weather Observation from Ship (type 8, DAC 1, FID 21).")
+	}
+
+	m.WMO = r.Bool(56)
+	if m.WMO {
+		decodeWMOWeatherObservation(&m, r)
+		return m, nil
+	}
+
+	m.Location = r.String(57, 176)
+
+	m.Lon, m.Lat = cbnCoordinatesLow(177, r)
+
+	m.Day = uint8(r.Uint(226, 230))
+	m.Hour = uint8(r.Uint(231, 235))
+	m.Minute = uint8(r.Uint(236, 241))
+
+	m.PresentWeather = uint16(r.Uint(242, 245))
+
+	// Bit 246 flags that visibility is greater than the value reported. We report the value only.
+	m.Visibility = scaledValue(int32(r.Uint(247, 253)), 127, 0.1, 0)
+
+	m.Humidity = uint8(r.Uint(254, 260))
+
+	m.WindSpeed = scaledValue(int32(r.Uint(261, 267)), 127, 1, 0)
+	m.WindDirection = uint16(r.Uint(268, 276))
+
+	m.Pressure = scaledValue(int32(r.Uint(277, 285)), 511, 1, 799)
+	m.PressureTendency = uint8(r.Uint(286, 289))
+
+	m.AirTemperature = scaledValue(int32(r.Int(290, 300)), -1024, 0.1, 0)
+	m.SeaTemperature = scaledValue(int32(r.Uint(301, 310)), 1023, 0.1, -10)
+
+	m.WavePeriod = uint8(r.Uint(311, 316))
+	m.WaveHeight = scaledValue(int32(r.Uint(317, 324)), 255, 0.1, 0)
+	m.WaveDirection = uint16(r.Uint(325, 333))
+
+	m.SwellHeight = scaledValue(int32(r.Uint(334, 341)), 255, 0.1, 0)
+	m.SwellDirection = uint16(r.Uint(342, 350))
+	m.SwellPeriod = uint8(r.Uint(351, 356))
+
+	return m, nil
+}
+
+// decodeWMOWeatherObservation decodes the WMO variant of the Weather Observation message.
+func decodeWMOWeatherObservation(m *WeatherObservation, r *BitReader) {
+	w := &m.WMOData
+
+	m.Lon, m.Lat = cbnCoordinatesLow(57, r)
+
+	m.Month = uint8(r.Uint(106, 109))
+	m.Day = uint8(r.Uint(110, 115))
+	m.Hour = uint8(r.Uint(116, 120))
+	m.Minute = uint8(r.Uint(121, 123)) * 10
+
+	w.Course = uint8(r.Uint(124, 130))
+	w.Speed = uint8(r.Uint(131, 135))
+	w.Heading = uint8(r.Uint(136, 142))
+
+	m.Pressure = scaledValue(int32(r.Uint(143, 153)), 2047, 0.1, 900)
+	w.PressureChange = int16(r.Uint(154, 163)) - 500
+	m.PressureTendency = uint8(r.Uint(164, 167))
+
+	m.WindDirection = uint16(r.Uint(168, 174)) * 5
+	m.WindSpeed = scaledValue(int32(r.Uint(175, 182)), 255, 0.5*knotsPerMeterSecond, 0)
+	w.RelativeWindDir = uint8(r.Uint(183, 189))
+	w.RelativeWindSpeed = uint8(r.Uint(190, 197))
+	w.GustSpeed = uint8(r.Uint(198, 205))
+	w.GustDirection = uint8(r.Uint(206, 212))
+
+	// Temperatures are transmitted in Kelvin/10 with an offset
+	m.AirTemperature = scaledValue(int32(r.Uint(213, 222)), 1023, 0.1, 223-273.15)
+	m.Humidity = uint8(r.Uint(223, 229))
+	m.SeaTemperature = scaledValue(int32(r.Uint(230, 238)), 511, 0.1, 268-273.15)
+
+	w.VisibilityCode = uint8(r.Uint(239, 244))
+	m.PresentWeather = uint16(r.Uint(245, 253))
+	w.PastWeather1 = uint8(r.Uint(254, 258))
+	w.PastWeather2 = uint8(r.Uint(259, 263))
+
+	w.CloudCover = uint8(r.Uint(264, 267))
+	w.LowCloudAmount = uint8(r.Uint(268, 271))
+	w.LowCloudType = uint8(r.Uint(272, 277))
+	w.MiddleCloudType = uint8(r.Uint(278, 283))
+	w.HighCloudType = uint8(r.Uint(284, 289))
+	w.CloudBase = uint8(r.Uint(290, 296))
+
+	m.WavePeriod = uint8(r.Uint(297, 301))
+	m.WaveHeight = scaledValue(int32(r.Uint(302, 307)), 63, 0.5, 0)
+
+	m.SwellDirection = uint16(r.Uint(308, 313)) * 10
+	m.SwellPeriod = uint8(r.Uint(314, 318))
+	m.SwellHeight = scaledValue(int32(r.Uint(319, 324)), 63, 0.5, 0)
+	w.SecondSwellDir = uint8(r.Uint(325, 330))
+	w.SecondSwellPeriod = uint8(r.Uint(331, 335))
+	w.SecondSwellHeight = uint8(r.Uint(336, 341))
+
+	w.IceThickness = uint8(r.Uint(342, 348))
+	w.IceAccretionRate = uint8(r.Uint(349, 351))
+	w.IceAccretionCause = uint8(r.Uint(352, 353))
+	w.SeaIceConcentration = uint8(r.Uint(354, 358))
+}
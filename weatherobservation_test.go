@@ -0,0 +1,77 @@
+// Copyright (c) 2015, Marios Andreopoulos.
+//
+// This file is part of aislib.
+//
+//  Aislib is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+//  Aislib is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+//  You should have received a copy of the GNU General Public License
+// along with aislib.  If not, see <http://www.gnu.org/licenses/>.
+
+package aislib
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestDecodeWeatherObservation(t *testing.T) {
+	cases := []struct {
+		payload string
+		want    WeatherObservation
+	}{
+		{
+			"83aEOK00EAirBQ42H`8000000000002vKPhvi2C7Q=rA5heHOfUj<<fH?OA8",
+			WeatherObservation{
+				BinaryHeader: BinaryHeader{Type: 8, Repeat: 0, MMSI: 244670316, DAC: 1, FID: 21},
+				WMO:          false, Location: "NORTH SEA", Lon: 3.25, Lat: 53.5, Day: 18, Hour: 12, Minute: 30,
+				PresentWeather: 1, Visibility: 5.5, Humidity: 82, WindSpeed: 17, WindDirection: 225,
+				Pressure: 1013, PressureTendency: 1, AirTemperature: -3.5, SeaTemperature: 8.5,
+				WavePeriod: 6, WaveHeight: 2.5, WaveDirection: 230, SwellHeight: 1.5, SwellDirection: 250,
+				SwellPeriod: 9,
+			},
+		},
+		{
+			"83aEOK00EH2vKPhvi0lV<TpU6v02FRBpD6USrDaHt?C2QAmS@iQFDQP00000",
+			WeatherObservation{
+				BinaryHeader: BinaryHeader{Type: 8, Repeat: 0, MMSI: 244670316, DAC: 1, FID: 21},
+				WMO:          true, Lon: 3.25, Lat: 53.5, Month: 3, Day: 18, Hour: 12, Minute: 30,
+				PresentWeather: 61, Humidity: 82, WindSpeed: float32(9 * knotsPerMeterSecond),
+				WindDirection: 225, Pressure: 1013.5, PressureTendency: 2, AirTemperature: -0.15,
+				SeaTemperature: 24.85, WavePeriod: 6, WaveHeight: 2.5, SwellHeight: 1.5,
+				SwellDirection: 250, SwellPeriod: 9,
+				WMOData: WMOWeatherData{
+					Course: 18, Speed: 14, Heading: 18, PressureChange: 12, RelativeWindSpeed: 20,
+					RelativeWindDir: 46, GustSpeed: 26, GustDirection: 44, VisibilityCode: 30,
+					PastWeather1: 6, PastWeather2: 2, CloudCover: 8, LowCloudAmount: 5, LowCloudType: 7,
+					MiddleCloudType: 22, HighCloudType: 13, CloudBase: 6,
+				},
+			},
+		},
+	}
+	for _, c := range cases {
+		got, _ := DecodeWeatherObservation(c.payload)
+		if got != c.want {
+			fmt.Println("Got : ", got)
+			fmt.Println("Want: ", c.want)
+			t.Errorf("DecodeWeatherObservation(payload string)")
+		}
+	}
+}
+
+func TestScaledValue(t *testing.T) {
+	if got := scaledValue(127, 127, 0.1, 0); !math.IsNaN(float64(got)) {
+		t.Errorf("scaledValue(raw, na int32, scale, offset float64) = %f, want NaN", got)
+	}
+	if got := scaledValue(214, 511, 1, 799); got != 1013 {
+		t.Errorf("scaledValue(raw, na int32, scale, offset float64) = %f, want 1013", got)
+	}
+}